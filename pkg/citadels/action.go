@@ -2,6 +2,10 @@ package citadels
 
 type CastFunc func(t *Table, caster *Player, ev Event) error
 
+// TargetsFunc returns every data of Event which CastFunc accepts in the current state of the table,
+// nil element means that the cast takes no data
+type TargetsFunc func(t *Table, caster *Player) []interface{}
+
 type Action struct {
 	Event *Event
 
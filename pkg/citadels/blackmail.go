@@ -0,0 +1,250 @@
+package citadels
+
+// threat is a face-down marker that the Blackmailer puts on a hero
+type threat struct {
+	// real is false for a bluff marker
+	real bool
+
+	// prompted shows that threatened player was asked to pay
+	prompted bool
+
+	// refused shows that threatened player refused to pay and the Blackmailer decides whether to reveal
+	refused bool
+
+	// answered shows that the marker no longer blocks the turn
+	answered bool
+}
+
+// placeThreats puts real and bluff markers on heroes with given names
+func (t *Table) placeThreats(blackmailer *Player, realHero, bluffHero string) error {
+	if realHero == bluffHero {
+		return ErrWrongEventData
+	}
+
+	real, ok := t.heroByName(realHero)
+	if !ok {
+		return ErrHeroNotExists
+	}
+	bluff, ok := t.heroByName(bluffHero)
+	if !ok {
+		return ErrHeroNotExists
+	}
+
+	if real.Turn == t.currentIndex || bluff.Turn == t.currentIndex {
+		return ErrCannotCastOnMyself
+	}
+	if real.Turn < t.currentIndex || bluff.Turn < t.currentIndex {
+		return ErrHeroAlreadyPlayed
+	}
+
+	t.blackmailer = blackmailer
+	t.threats = map[int]*threat{
+		real.Turn:  {real: true},
+		bluff.Turn: {real: false},
+	}
+
+	// heroes are listed by turn so the order does not tell which marker is real
+	heroes := []string{real.Name, bluff.Name}
+	if bluff.Turn < real.Turn {
+		heroes = []string{bluff.Name, real.Name}
+	}
+
+	blackmailer.Notify(Event{
+		Type: EventTypeThreatsPlacedPrivate,
+		Data: EventThreats{
+			BlackmailerID: blackmailer.ID,
+			Heroes:        heroes,
+			RealHero:      real.Name,
+		},
+	})
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeThreatsPlaced,
+		Data: EventThreats{
+			BlackmailerID: blackmailer.ID,
+			Heroes:        heroes,
+		},
+	})
+	return nil
+}
+
+// currentThreat returns marker of the hero which is taking a turn right now
+func (t *Table) currentThreat() (*threat, bool) {
+	th, ok := t.threats[t.currentIndex]
+	return th, ok
+}
+
+// threatUnresolved reports whether current turn waits for a decision about the threat
+func (t *Table) threatUnresolved() bool {
+	th, ok := t.currentThreat()
+	return ok && th.prompted && !th.answered
+}
+
+// threatPending reports whether p has not been asked about the threat of current turn yet
+func (t *Table) threatPending(p *Player) bool {
+	th, ok := t.currentThreat()
+	return ok && !th.prompted && t.blackmailer != nil && t.blackmailer.ID != p.ID
+}
+
+// promptThreat asks threatened player to pay the Blackmailer,
+// returns false if there is nothing to ask
+func (t *Table) promptThreat(p *Player) bool {
+	if !t.threatPending(p) {
+		return false
+	}
+	th, _ := t.currentThreat()
+	th.prompted = true
+
+	p.Notify(Event{
+		Type: EventTypeChooseThreatPayment,
+		Data: EventThreatPayment{
+			BlackmailerID: t.blackmailer.ID,
+			Price:         p.Coins / 2,
+		},
+	})
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypePlayerThreatened,
+		Data: EventPlayerID{PlayerID: p.ID},
+	})
+	return true
+}
+
+// PayThreat is threatened player's answer to the Blackmailer,
+// if pay is true half of player's coins goes to the Blackmailer and the marker is removed
+func (t *Table) PayThreat(pID string, pay bool) error {
+	t.Lock()
+	defer t.Unlock()
+	return t.record(Command{Type: CommandTypePayThreat, PlayerID: PlayerID(pID), Answer: pay}, func() error {
+		return t.payThreat(PlayerID(pID), pay)
+	})
+}
+
+// payThreat answers the threat of current turn
+func (t *Table) payThreat(pID PlayerID, pay bool) error {
+	p, ok := t.players[pID]
+	if !ok {
+		return ErrPlayerNotExists
+	}
+
+	th, err := t.checkPayThreat(p)
+	if err != nil {
+		return err
+	}
+
+	if !pay {
+		th.refused = true
+		t.blackmailer.Notify(Event{
+			Type: EventTypeChooseThreatReveal,
+			Data: EventPlayerID{PlayerID: p.ID},
+		})
+		t.doBroadcastEvent(Event{
+			Type: EventTypeThreatRefused,
+			Data: EventPlayerID{PlayerID: p.ID},
+		})
+		return nil
+	}
+
+	price := p.Coins / 2
+	p.AddCoins(-price)
+	t.blackmailer.AddCoins(price)
+	delete(t.threats, t.currentIndex)
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeThreatPaid,
+		Data: EventSteal{
+			FromID: p.ID,
+			To:     t.blackmailer.ID,
+			Count:  price,
+		},
+	})
+
+	t.afterResources(p)
+	return nil
+}
+
+// RevealThreat is the Blackmailer's answer to the refusal,
+// revealed real marker takes all coins of threatened player, revealed bluff does nothing
+func (t *Table) RevealThreat(pID string, reveal bool) error {
+	t.Lock()
+	defer t.Unlock()
+	return t.record(Command{Type: CommandTypeRevealThreat, PlayerID: PlayerID(pID), Answer: reveal}, func() error {
+		return t.revealThreat(PlayerID(pID), reveal)
+	})
+}
+
+// revealThreat answers the refusal of threatened player
+func (t *Table) revealThreat(pID PlayerID, reveal bool) error {
+	th, err := t.checkRevealThreat(pID)
+	if err != nil {
+		return err
+	}
+
+	t.resolveThreat(th, reveal)
+	t.afterResources(t.turn)
+	return nil
+}
+
+// checkPayThreat returns the threat which p has to answer
+func (t *Table) checkPayThreat(p *Player) (*threat, error) {
+	if t.currentPhase != ActionPhase || t.turn.ID != p.ID {
+		return nil, ErrNotYourTurn
+	}
+
+	th, ok := t.currentThreat()
+	if !ok || !th.prompted || th.refused || th.answered {
+		return nil, ErrNoThreat
+	}
+	return th, nil
+}
+
+// checkRevealThreat returns the refused threat which the Blackmailer with id pID decides about
+func (t *Table) checkRevealThreat(pID PlayerID) (*threat, error) {
+	if t.blackmailer == nil || t.blackmailer.ID != pID {
+		return nil, ErrNoThreat
+	}
+
+	th, ok := t.currentThreat()
+	if t.currentPhase != ActionPhase || !ok || !th.refused || th.answered {
+		return nil, ErrNoThreat
+	}
+	return th, nil
+}
+
+// resolveThreat finishes refused threat of current turn
+func (t *Table) resolveThreat(th *threat, reveal bool) {
+	th.answered = true
+	if !reveal {
+		return
+	}
+
+	p := t.turn
+	var count int
+	if th.real {
+		count = p.Coins
+		p.AddCoins(-count)
+		t.blackmailer.AddCoins(count)
+	}
+	delete(t.threats, t.currentIndex)
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeThreatRevealed,
+		Data: EventThreatRevealed{
+			PlayerID:      p.ID,
+			BlackmailerID: t.blackmailer.ID,
+			HeroName:      t.turnHero.Name,
+			Real:          th.real,
+			Count:         count,
+		},
+	})
+}
+
+// expireThreat resolves the threat when the turn ends without a decision,
+// silence of threatened player is a refusal and the Blackmailer always reveals a real marker
+func (t *Table) expireThreat() {
+	th, ok := t.currentThreat()
+	if !ok || !th.prompted || th.answered {
+		return
+	}
+	t.resolveThreat(th, th.real)
+}
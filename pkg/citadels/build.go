@@ -0,0 +1,63 @@
+package citadels
+
+// Build describes a quarter which is being built
+type Build struct {
+	Player *Player
+
+	// Quarter is card from Player.AvailableQuarters
+	Quarter Quarter
+
+	// Cost is how many coins the build takes, heroes may change it
+	Cost int
+}
+
+// BuildHookFunc is called by the table when a quarter is built,
+// holder is player of the hero which owns the hook, nil if nobody plays the hero
+type BuildHookFunc func(t *Table, holder *Player, b *Build)
+
+// beforeBuild lets heroes of the set modify the build
+func (t *Table) beforeBuild(b *Build) {
+	for _, hero := range t.heroes {
+		if hero.Skill.BeforeBuild != nil {
+			hero.Skill.BeforeBuild(t, t.heroHolder(hero), b)
+		}
+	}
+	if b.Cost < 0 {
+		b.Cost = 0
+	}
+}
+
+// afterBuild lets heroes of the set react to the build
+func (t *Table) afterBuild(b *Build) {
+	for _, hero := range t.heroes {
+		if hero.Skill.AfterBuild != nil {
+			hero.Skill.AfterBuild(t, t.heroHolder(hero), b)
+		}
+	}
+}
+
+// collectCustoms takes a coin from p for the Customs Officer,
+// the coin waits on the card until the Customs Officer is revealed
+func (t *Table) collectCustoms(p *Player) {
+	if p.Coins == 0 {
+		return
+	}
+	p.AddCoins(-1)
+
+	var collectorID PlayerID
+	if t.customsOfficer != nil {
+		t.customsOfficer.AddCoins(1)
+		collectorID = t.customsOfficer.ID
+	} else {
+		t.customsPool++
+	}
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeTaxCollected,
+		Data: EventTax{
+			PlayerID:    p.ID,
+			CollectorID: collectorID,
+			Amount:      1,
+		},
+	})
+}
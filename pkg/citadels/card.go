@@ -1,7 +1,5 @@
 package citadels
 
-import "encoding/json"
-
 // Quarter Types
 const (
 	QuarterTypeNoble = "Noble"
@@ -27,11 +25,41 @@ const (
 const (
 	SkillTypeAnytime = "skill.type.anytime"
 	SkillTypeAtStart = "skill.type.at.start"
+
+	// SkillTypePassive works by itself and can not be cast
+	SkillTypePassive = "skill.type.passive"
 )
 
+// HookFunc is called by the table at specific moments of the game
+type HookFunc func(t *Table, p *Player)
+
 type Skill struct {
-	Type string
-	Do CastFunc
+	Type string `json:"type"`
+	Do CastFunc `json:"-"`
+
+	// Payload is zero value of data which Do takes, CastSkill events of the hero are decoded into its type
+	Payload interface{} `json:"-"`
+
+	// Targets lists every data which Do accepts right now,
+	// skill without it is cast with no data
+	Targets TargetsFunc `json:"-"`
+
+	// OnTurnStart is called when turn of the hero begins
+	OnTurnStart HookFunc `json:"-"`
+
+	// OnTurnEnd is called when turn of the hero ends
+	OnTurnEnd HookFunc `json:"-"`
+
+	// OnRoundEnd is called for every hero of the set when the round ends,
+	// p is nil if nobody played the hero this round
+	OnRoundEnd HookFunc `json:"-"`
+
+	// BeforeBuild is called for every hero of the set before any player builds a quarter
+	// and can change the cost of the build
+	BeforeBuild BuildHookFunc `json:"-"`
+
+	// AfterBuild is called for every hero of the set after any player built a quarter
+	AfterBuild BuildHookFunc `json:"-"`
 }
 
 type Hero struct {
@@ -42,27 +70,35 @@ type Hero struct {
 	// heroes makes moves in specific order from 1 to 9
 	Turn int `json:"turn"`
 
-	Skill Skill
+	// Protected heroes can not lose their quarters to the Warlord
+	Protected bool `json:"protected"`
+
+	// BuildLimit is how many quarters the hero can build per turn, 0 means one quarter
+	BuildLimit int `json:"build_limit"`
+
+	// BonusCards is how many cards the hero draws from the deck at the start of the turn
+	BonusCards int `json:"bonus_cards"`
+
+	Skill Skill `json:"skill"`
 }
 
+// Emperor must give the crown to another player at the start of his turn,
+// new king pays the Emperor one coin or one card
 func Emperor() Hero {
 	return Hero{
 		Name:  "Emperor",
-		Turn:  4,
+		Turn:  EmperorTurn,
 		Skill: Skill{
-			Type: SkillTypeAnytime,
+			Type: SkillTypeAtStart,
+			Payload: EventEmperorSkill{},
 			Do: func(t *Table, caster *Player, ev Event) error {
-				b, err := json.Marshal(ev.Data)
-				if err != nil {
-					return err
-				}
 				var e EventEmperorSkill
-				err = json.Unmarshal(b, &e)
+				err := decodeEventData(ev, &e)
 				if err != nil {
-					return ErrWrongEventData
+					return err
 				}
 
-				target, ok := t.PlayerByID(string(e.TargetID))
+				target, ok := t.playerByID(string(e.TargetID))
 				if !ok {
 					return ErrPlayerNotExists
 				}
@@ -71,6 +107,12 @@ func Emperor() Hero {
 					return ErrCannotCastOnMyself
 				}
 
+				if target.ID == t.king.ID {
+					return ErrAlreadyKing
+				}
+
+				t.moveCrown(target)
+
 				if e.Coin {
 					if target.Coins > 0 {
 						target.giveCoins(caster, 1)
@@ -91,110 +133,320 @@ func Emperor() Hero {
 
 				return nil
 			},
+			Targets: func(t *Table, caster *Player) []interface{} {
+				targets := make([]interface{}, 0)
+				for _, p := range t.othersOf(caster) {
+					if p.ID == t.king.ID {
+						continue
+					}
+					targets = append(targets,
+						EventEmperorSkill{TargetID: p.ID, Coin: true},
+						EventEmperorSkill{TargetID: p.ID, Coin: false},
+					)
+				}
+				return targets
+			},
+			// Emperor who did not give the crown away loses the choice
+			OnTurnEnd: func(t *Table, p *Player) {
+				if !p.skillUsed {
+					t.passCrown(p)
+				}
+			},
+			// crown moves even if the Emperor is absent this round
+			OnRoundEnd: func(t *Table, p *Player) {
+				if !t.crownMoved {
+					t.passCrown(t.king)
+				}
+			},
 		},
 	}
 }
 
+// Witch names a hero at the start of her turn and her turn pauses,
+// when the bewitched hero is revealed, its player only collects resources
+// and the Witch finishes the turn using bewitched hero's skill
 func Witch() Hero {
 	return Hero{
 		Name:  "Witch",
 		Turn:  1,
 		Skill: Skill{
-			Type: SkillTypeAnytime,
+			Type: SkillTypeAtStart,
+			Payload: EventWitchSkill{},
 			Do: func(t *Table, caster *Player, ev Event) error {
+				var e EventWitchSkill
+				err := decodeEventData(ev, &e)
+				if err != nil {
+					return err
+				}
+
+				hero, ok := t.heroByName(e.HeroName)
+				if !ok {
+					return ErrHeroNotExists
+				}
+
+				if hero.Turn == t.currentIndex {
+					return ErrCannotCastOnMyself
+				}
+				if hero.Turn < t.currentIndex {
+					return ErrHeroAlreadyPlayed
+				}
+
+				t.witch = caster
+				t.bewitchedTurn = hero.Turn
+
+				t.doBroadcastEvent(Event{
+					Type: EventTypeHeroBewitched,
+					Data: EventHeroBewitched{
+						HeroName: hero.Name,
+						Turn:     hero.Turn,
+					},
+				})
+
+				t.endTurn()
 				return nil
 			},
+			Targets: func(t *Table, caster *Player) []interface{} {
+				targets := make([]interface{}, 0)
+				for _, hero := range t.heroesAfter(t.currentIndex) {
+					targets = append(targets, EventWitchSkill{HeroName: hero.Name})
+				}
+				return targets
+			},
 		},
 	}
 }
 
+// Blackmailer puts real and bluff threat markers on two heroes,
+// threatened player pays half of his coins or risks losing all of them
 func Blackmailer() Hero {
 	return Hero{
 		Name:  "Blackmailer",
 		Turn:  2,
 		Skill: Skill{
 			Type: SkillTypeAnytime,
+			Payload: EventBlackmailerSkill{},
 			Do: func(t *Table, caster *Player, ev Event) error {
-				return nil
+				var e EventBlackmailerSkill
+				err := decodeEventData(ev, &e)
+				if err != nil {
+					return err
+				}
+
+				return t.placeThreats(caster, e.RealHero, e.BluffHero)
+			},
+			Targets: func(t *Table, caster *Player) []interface{} {
+				targets := make([]interface{}, 0)
+				heroes := t.heroesAfter(t.currentIndex)
+				for _, real := range heroes {
+					for _, bluff := range heroes {
+						if real.Name != bluff.Name {
+							targets = append(targets, EventBlackmailerSkill{RealHero: real.Name, BluffHero: bluff.Name})
+						}
+					}
+				}
+				return targets
 			},
 		},
 	}
 }
 
+// Enchantress either swaps her hand with another player
+// or exchanges any number of her cards with the deck
 func Enchantress() Hero {
 	return Hero{
 		Name:  "Enchantress",
-		Turn:  3,
+		Turn:  EnchantressTurn,
 		Skill: Skill{
 			Type: SkillTypeAnytime,
+			Payload: EventEnchantressSkill{},
 			Do: func(t *Table, caster *Player, ev Event) error {
-				return nil
+				var e EventEnchantressSkill
+				err := decodeEventData(ev, &e)
+				if err != nil {
+					return err
+				}
+
+				if e.Swap != nil && e.Exchange == nil {
+					return t.swapHands(caster, e.Swap.TargetID)
+				}
+				if e.Exchange != nil && e.Swap == nil {
+					return t.exchangeCards(caster, e.Exchange.Cards)
+				}
+				return ErrWrongEventData
+			},
+			Targets: func(t *Table, caster *Player) []interface{} {
+				targets := make([]interface{}, 0)
+				for _, p := range t.othersOf(caster) {
+					targets = append(targets, EventEnchantressSkill{Swap: &EventSwapHands{TargetID: p.ID}})
+				}
+				// parts of the hand are too many to list, the exchange names the whole hand
+				targets = append(targets, EventEnchantressSkill{Exchange: &EventExchangeCards{Cards: quarterNames(caster.AvailableQuarters)}})
+				return targets
 			},
 		},
 	}
 }
 
+// Abat takes coins or cards for every Spiritual quarter and one coin from the richest player,
+// his quarters are protected from the Warlord
 func Abat() Hero {
 	return Hero{
-		Name:  "Abat",
-		Turn:  5,
+		Name:      "Abat",
+		Turn:      5,
+		Protected: true,
 		Skill: Skill{
 			Type: SkillTypeAnytime,
+			Payload: EventAbatSkill{},
 			Do: func(t *Table, caster *Player, ev Event) error {
+				var e EventAbatSkill
+				err := decodeEventData(ev, &e)
+				if err != nil {
+					return err
+				}
+
+				err = t.collectIncome(caster, QuarterTypeSpiritual, e.Coins)
+				if err != nil {
+					return err
+				}
+
+				t.collectTithe(caster)
 				return nil
 			},
+			Targets: func(t *Table, caster *Player) []interface{} {
+				targets := make([]interface{}, 0)
+				for coins := 0; coins <= caster.quartersOfType(QuarterTypeSpiritual); coins++ {
+					targets = append(targets, EventAbatSkill{Coins: coins})
+				}
+				return targets
+			},
 		},
 	}
 }
 
+// Alchemist gets back all coins spent on quarters at the end of his turn
 func Alchemist() Hero {
 	return Hero{
 		Name:  "Alchemist",
 		Turn:  6,
 		Skill: Skill{
-			Type: SkillTypeAnytime,
-			Do: func(t *Table, caster *Player, ev Event) error {
-				return nil
+			Type: SkillTypePassive,
+			OnTurnEnd: func(t *Table, p *Player) {
+				if p.spentThisTurn == 0 {
+					return
+				}
+				refund := p.spentThisTurn
+				p.spentThisTurn = 0
+				p.AddCoins(refund)
+
+				t.doBroadcastEvent(Event{
+					Type: EventTypeCoinsRefunded,
+					Data: EventCoinGive{
+						To:     p.ID,
+						Amount: refund,
+						Sum:    p.Coins,
+					},
+				})
 			},
 		},
 	}
 }
 
+// Architect draws two extra cards and can build up to three quarters
 func Architect() Hero {
 	return Hero{
-		Name:  "Architect",
-		Turn:  7,
+		Name:       "Architect",
+		Turn:       7,
+		BuildLimit: 3,
+		BonusCards: 2,
 		Skill: Skill{
-			Type: SkillTypeAnytime,
-			Do: func(t *Table, caster *Player, ev Event) error {
-				return nil
-			},
+			Type: SkillTypePassive,
 		},
 	}
 }
 
+// Warlord takes a coin for every Military quarter
+// and can destroy a quarter of another player paying its price minus one
 func Warlord() Hero {
 	return Hero{
 		Name:  "Warlord",
 		Turn:  8,
 		Skill: Skill{
 			Type: SkillTypeAnytime,
+			Payload: EventWarlordSkill{},
 			Do: func(t *Table, caster *Player, ev Event) error {
-				return nil
+				var e EventWarlordSkill
+				err := decodeEventData(ev, &e)
+				if err != nil {
+					return err
+				}
+
+				return t.destroyQuarter(caster, e.TargetID, e.Quarter)
+			},
+			Targets: func(t *Table, caster *Player) []interface{} {
+				targets := make([]interface{}, 0)
+				for _, p := range t.othersOf(caster) {
+					for _, name := range distinctNames(p.CompletedQuarters) {
+						if _, err := t.destruction(caster, p.ID, name); err == nil {
+							targets = append(targets, EventWarlordSkill{TargetID: p.ID, Quarter: name})
+						}
+					}
+				}
+				return targets
+			},
+			OnTurnStart: func(t *Table, p *Player) {
+				t.takeIncome(p, QuarterTypeMilitary)
 			},
 		},
 	}
 }
 
-
+// CustomsOfficer takes a coin from every quarter built by other players this round.
+// He plays last, so the tax is collected from the start of the round: coins paid
+// before he is revealed wait on his card and go back to the bank at round end
+// if he never takes his turn
 func CustomsOfficer() Hero {
 	return Hero{
 		Name:  "CustomsOfficer",
 		Turn:  9,
 		Skill: Skill{
-			Type: SkillTypeAnytime,
-			Do: func(t *Table, caster *Player, ev Event) error {
-				return nil
+			Type: SkillTypePassive,
+			AfterBuild: func(t *Table, holder *Player, b *Build) {
+				if holder == nil || b.Player.ID == holder.ID {
+					return
+				}
+				if t.customsOfficer != nil && b.Player.ID == t.customsOfficer.ID {
+					return
+				}
+				t.collectCustoms(b.Player)
+			},
+			OnTurnStart: func(t *Table, p *Player) {
+				t.customsOfficer = p
+				if t.customsPool == 0 {
+					return
+				}
+
+				pool := t.customsPool
+				t.customsPool = 0
+				p.AddCoins(pool)
+				t.doBroadcastEvent(Event{
+					Type: EventTypeCoinsGive,
+					Data: EventCoinGive{
+						To:     p.ID,
+						Amount: pool,
+						Sum:    p.Coins,
+					},
+				})
+			},
+			OnRoundEnd: func(t *Table, p *Player) {
+				if t.customsPool == 0 {
+					return
+				}
+
+				t.doBroadcastEvent(Event{
+					Type: EventTypeTaxReturned,
+					Data: EventTax{Amount: t.customsPool},
+				})
+				t.customsPool = 0
 			},
 		},
 	}
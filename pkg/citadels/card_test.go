@@ -0,0 +1,559 @@
+package citadels
+
+import "testing"
+
+// TestWitch drives a table through a round where the Witch bewitches the Emperor
+func TestWitch(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Blackmailer(), Emperor(), Warlord())
+	witch, blackmailer, emperor, warlord := players[0], players[1], players[2], players[3]
+
+	table.startActionPhase()
+	if table.Turn().ID != witch.ID {
+		t.Fatal("witch should take the first turn")
+	}
+
+	err := table.CastSkill(string(witch.ID), Event{Data: EventWitchSkill{HeroName: "Witch"}})
+	if err != ErrCannotCastOnMyself {
+		t.Fatal("witch should not bewitch herself, got ", err)
+	}
+
+	err = table.CastSkill(string(witch.ID), Event{Data: EventWitchSkill{HeroName: "Emperor"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Turn().ID != blackmailer.ID {
+		t.Fatal("witch's turn should pause after bewitching")
+	}
+
+	table.EndTurn(blackmailer.ID)
+	if table.Turn().ID != emperor.ID {
+		t.Fatal("emperor should collect resources")
+	}
+	if !hasEvent(drainEvents(warlord), EventTypePlayerBewitched) {
+		t.Fatal("players should know that emperor is bewitched")
+	}
+
+	emperor.AddCoins(5)
+	emperor.AddQuarter(table.deck[0])
+	table.BuildQuarter(table.deck[0], string(emperor.ID))
+	if len(emperor.CompletedQuarters) != 0 {
+		t.Fatal("bewitched player should not build")
+	}
+	err = table.CastSkill(string(emperor.ID), Event{Data: EventEmperorSkill{TargetID: warlord.ID, Coin: true}})
+	if err != ErrPlayerBewitched {
+		t.Fatal("bewitched player should not cast skills, got ", err)
+	}
+
+	table.MakeAction(ActionTypeCoin, string(emperor.ID))
+	if emperor.Coins != 7 {
+		t.Fatal("bewitched player should keep his resources")
+	}
+	if table.Turn().ID != witch.ID {
+		t.Fatal("witch should take over the turn")
+	}
+	if table.turnHero.Name != "Emperor" {
+		t.Fatal("witch should use bewitched hero")
+	}
+	if !hasEvent(drainEvents(warlord), EventTypeWitchTakesTurn) {
+		t.Fatal("players should know that witch takes over the turn")
+	}
+
+	coins := witch.Coins
+	table.MakeAction(ActionTypeCoin, string(witch.ID))
+	if witch.Coins != coins {
+		t.Fatal("witch should not collect resources of bewitched hero")
+	}
+
+	witch.AddCoins(1)
+	witch.AddQuarter(table.deck[1])
+	table.BuildQuarter(table.deck[1], string(witch.ID))
+	if len(witch.CompletedQuarters) != 0 {
+		t.Fatal("emperor's crown should be moved before building")
+	}
+	err = table.CastSkill(string(witch.ID), Event{Data: EventEmperorSkill{TargetID: warlord.ID, Coin: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.King().ID != warlord.ID {
+		t.Fatal("witch should use emperor's skill")
+	}
+	table.BuildQuarter(table.deck[1], string(witch.ID))
+	if len(witch.CompletedQuarters) != 1 {
+		t.Fatal("witch should build during bewitched turn")
+	}
+
+	table.EndTurn(witch.ID)
+	if table.Turn().ID != warlord.ID {
+		t.Fatal("round should continue after bewitched turn")
+	}
+
+	table.EndTurn(warlord.ID)
+	if table.bewitchedPlayer != nil || table.witch != nil {
+		t.Fatal("bewitchment should last one round")
+	}
+}
+
+// TestWitchCards checks that bewitched player hands the turn over after choosing a card
+func TestWitchCards(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Emperor(), Warlord(), CustomsOfficer())
+	witch, emperor := players[0], players[1]
+
+	table.startActionPhase()
+	err := table.CastSkill(string(witch.ID), Event{Data: EventWitchSkill{HeroName: "Emperor"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table.MakeAction(ActionTypeCards, string(emperor.ID))
+	if table.Turn().ID != emperor.ID {
+		t.Fatal("bewitched player should finish collecting resources")
+	}
+	table.SelectCard(emperor.currentCardsChoice[0].Name, string(emperor.ID))
+	if len(emperor.AvailableQuarters) != 1 {
+		t.Fatal("bewitched player should keep the card")
+	}
+	if table.Turn().ID != witch.ID {
+		t.Fatal("witch should take over the turn")
+	}
+}
+
+// TestWitchPlayedHero checks that the Witch can not name a hero whose turn has passed
+func TestWitchPlayedHero(t *testing.T) {
+	late := Witch()
+	late.Turn = 3
+	table, players := newTestTable(t, Blackmailer(), late, Emperor(), Warlord())
+	blackmailer, witch := players[0], players[1]
+
+	table.startActionPhase()
+	table.EndTurn(blackmailer.ID)
+	if table.Turn().ID != witch.ID {
+		t.Fatal("witch should take the turn after the blackmailer")
+	}
+
+	err := table.CastSkill(string(witch.ID), Event{Data: EventWitchSkill{HeroName: "Blackmailer"}})
+	if err != ErrHeroAlreadyPlayed {
+		t.Fatal("hero whose turn has passed should not be bewitched, got ", err)
+	}
+	if ErrorTypeOf(err) != ErrorTypeHeroAlreadyPlayed {
+		t.Fatal("played hero should have its own code, got ", ErrorTypeOf(err))
+	}
+}
+
+// TestBlackmailerTargets checks that markers go only to heroes whose turn is ahead
+func TestBlackmailerTargets(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Blackmailer(), Emperor(), Warlord())
+	blackmailer := players[1]
+	table.currentIndex = 1
+	table.startActionPhase()
+
+	cast := func(real, bluff string) error {
+		return table.CastSkill(string(blackmailer.ID), Event{Data: EventBlackmailerSkill{RealHero: real, BluffHero: bluff}})
+	}
+	if err := cast("Emperor", "Witch"); err != ErrHeroAlreadyPlayed {
+		t.Fatal("hero who already played should not be threatened, got ", err)
+	}
+	if err := cast("Blackmailer", "Emperor"); err != ErrCannotCastOnMyself {
+		t.Fatal("blackmailer should not threaten himself, got ", err)
+	}
+	if err := table.Apply(blackmailer.ID, CastSkillCommand{Data: EventBlackmailerSkill{RealHero: "Witch", BluffHero: "Warlord"}}); ErrorTypeOf(err) != ErrorTypeHeroAlreadyPlayed {
+		t.Fatal("client should get the code of played hero, got ", err)
+	}
+}
+
+// TestBlackmailer checks paying and revealing of threat markers
+func TestBlackmailer(t *testing.T) {
+	table, players := newTestTable(t, Blackmailer(), Enchantress(), Emperor(), Warlord())
+	blackmailer, enchantress, emperor := players[0], players[1], players[2]
+
+	table.startActionPhase()
+	err := table.CastSkill(string(blackmailer.ID), Event{Data: EventBlackmailerSkill{RealHero: "Emperor", BluffHero: "Enchantress"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasEvent(drainEvents(blackmailer), EventTypeThreatsPlacedPrivate) {
+		t.Fatal("blackmailer should know which marker is real")
+	}
+	table.EndTurn(blackmailer.ID)
+
+	// enchantress pays half of her coins
+	enchantress.AddCoins(3)
+	table.MakeAction(ActionTypeCoin, string(enchantress.ID))
+	if !hasEvent(drainEvents(enchantress), EventTypeChooseThreatPayment) {
+		t.Fatal("threatened player should be asked to pay")
+	}
+	table.EndTurn(enchantress.ID)
+	if table.Turn().ID != enchantress.ID {
+		t.Fatal("threatened player should answer before ending the turn")
+	}
+	err = table.PayThreat(string(enchantress.ID), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if enchantress.Coins != 3 || blackmailer.Coins != 2 {
+		t.Fatal("half of the coins should go to the blackmailer")
+	}
+	table.EndTurn(enchantress.ID)
+
+	// emperor refuses and loses everything
+	table.MakeAction(ActionTypeCoin, string(emperor.ID))
+	err = table.PayThreat(string(emperor.ID), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasEvent(drainEvents(blackmailer), EventTypeChooseThreatReveal) {
+		t.Fatal("blackmailer should be asked to reveal the marker")
+	}
+	err = table.RevealThreat(string(blackmailer.ID), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if emperor.Coins != 0 || blackmailer.Coins != 4 {
+		t.Fatal("revealed real marker should take all coins")
+	}
+
+	table.EndTurn(emperor.ID)
+	table.EndTurn(players[3].ID)
+	if table.threats != nil {
+		t.Fatal("markers should be removed at the end of the round")
+	}
+}
+
+// TestEnchantress checks both options of the Enchantress
+func TestEnchantress(t *testing.T) {
+	table, players := newTestTable(t, Enchantress(), Emperor(), Warlord(), CustomsOfficer())
+	enchantress, emperor := players[0], players[1]
+	enchantress.AvailableQuarters = table.drawFromDeck(3)
+	emperor.AvailableQuarters = table.drawFromDeck(1)
+
+	table.startActionPhase()
+	err := table.CastSkill(string(enchantress.ID), Event{Data: EventEnchantressSkill{
+		Swap:     &EventSwapHands{TargetID: emperor.ID},
+		Exchange: &EventExchangeCards{},
+	}})
+	if err != ErrWrongEventData {
+		t.Fatal("only one option should be allowed, got ", err)
+	}
+
+	err = table.CastSkill(string(enchantress.ID), Event{Data: EventEnchantressSkill{
+		Swap: &EventSwapHands{TargetID: emperor.ID},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enchantress.AvailableQuarters) != 1 || len(emperor.AvailableQuarters) != 3 {
+		t.Fatal("hands should be swapped")
+	}
+	if !hasEvent(drainEvents(emperor), EventTypeHandsSwappedPrivate) {
+		t.Fatal("target should receive his new hand")
+	}
+
+	// exchange option is checked directly because the skill is cast once per turn
+	card := enchantress.AvailableQuarters[0]
+	deckSize := len(table.deck)
+	err = table.exchangeCards(enchantress, []string{card.Name})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(enchantress.AvailableQuarters) != 1 || enchantress.AvailableQuarters[0].Name == card.Name {
+		t.Fatal("card should be exchanged")
+	}
+	if len(table.deck) != deckSize || table.deck[len(table.deck)-1].Name != card.Name {
+		t.Fatal("discarded card should go to the bottom of the deck")
+	}
+	if table.exchangeCards(enchantress, []string{card.Name}) != ErrCardNotInHand {
+		t.Fatal("only cards from the hand can be exchanged")
+	}
+}
+
+// TestEmperor checks that the crown moves every round
+func TestEmperor(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Enchantress(), Emperor(), Warlord())
+	witch, enchantress, emperor, warlord := players[0], players[1], players[2], players[3]
+	warlord.AddCoins(2)
+
+	table.startActionPhase()
+	table.EndTurn(witch.ID)
+	table.EndTurn(enchantress.ID)
+
+	err := table.CastSkill(string(emperor.ID), Event{Data: EventEmperorSkill{TargetID: emperor.ID}})
+	if err != ErrCannotCastOnMyself {
+		t.Fatal("emperor should not crown himself, got ", err)
+	}
+	err = table.CastSkill(string(emperor.ID), Event{Data: EventEmperorSkill{TargetID: witch.ID}})
+	if err != ErrAlreadyKing {
+		t.Fatal("crown should move to another player, got ", err)
+	}
+	err = table.CastSkill(string(emperor.ID), Event{Data: EventEmperorSkill{TargetID: warlord.ID, Coin: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.King().ID != warlord.ID {
+		t.Fatal("warlord should become a king")
+	}
+	if warlord.Coins != 1 || emperor.Coins != 1 {
+		t.Fatal("new king should pay the emperor")
+	}
+	if !hasEvent(drainEvents(witch), EventTypeCrownMoved) {
+		t.Fatal("crown change should be announced")
+	}
+
+	table.EndTurn(emperor.ID)
+	table.EndTurn(warlord.ID)
+	if table.selecting.ID != warlord.ID {
+		t.Fatal("next round should start from the new king")
+	}
+}
+
+// TestEmperorAbsent checks that the crown moves clockwise without the Emperor
+func TestEmperorAbsent(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Enchantress(), Abat(), Warlord())
+
+	table.startActionPhase()
+	for _, p := range players {
+		table.EndTurn(p.ID)
+	}
+	if table.King().ID != players[1].ID {
+		t.Fatal("crown should move to the next player clockwise")
+	}
+}
+
+// TestEmperorSkipsSkill checks that the crown moves when the Emperor does not give it away
+func TestEmperorSkipsSkill(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Enchantress(), Emperor(), Warlord())
+
+	table.startActionPhase()
+	for _, p := range players[:3] {
+		table.EndTurn(p.ID)
+	}
+	if table.King().ID != players[3].ID {
+		t.Fatal("crown should move to the player after the emperor")
+	}
+}
+
+// TestAbat checks Spiritual income and the tithe of the richest player
+func TestAbat(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Enchantress(), Abat(), Warlord())
+	abat, enchantress, warlord := players[2], players[1], players[3]
+	abat.CompletedQuarters = []Quarter{
+		{Name: "temple", Type: QuarterTypeSpiritual, Price: 1},
+		{Name: "church", Type: QuarterTypeSpiritual, Price: 2},
+		{Name: "tavern", Type: QuarterTypeTrade, Price: 1},
+	}
+	abat.AddCoins(1)
+	enchantress.AddCoins(4)
+	warlord.AddCoins(4)
+
+	table.startActionPhase()
+	table.EndTurn(players[0].ID)
+	table.EndTurn(enchantress.ID)
+
+	err := table.CastSkill(string(abat.ID), Event{Data: EventAbatSkill{Coins: 3}})
+	if err != ErrWrongEventData {
+		t.Fatal("income should be limited by Spiritual quarters, got ", err)
+	}
+	err = table.CastSkill(string(abat.ID), Event{Data: EventAbatSkill{Coins: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(abat.AvailableQuarters) != 1 {
+		t.Fatal("abat should take a card")
+	}
+	if abat.Coins != 3 {
+		t.Fatal("abat should take a coin of income and a coin of tithe")
+	}
+	if warlord.Coins != 3 || enchantress.Coins != 4 {
+		t.Fatal("tie should be won by the first player clockwise from the abat")
+	}
+
+	if _, ok := table.richestPlayer(enchantress); ok {
+		t.Fatal("nobody should pay to a player who is at least as rich")
+	}
+}
+
+// TestAlchemist checks that coins spent on quarters come back at the end of the turn
+func TestAlchemist(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Enchantress(), Alchemist(), Warlord())
+	alchemist := players[2]
+	alchemist.AvailableQuarters = []Quarter{
+		{Name: "tavern", Type: QuarterTypeTrade, Price: 2},
+		{Name: "castle", Type: QuarterTypeNoble, Price: 4},
+	}
+	alchemist.AddCoins(4)
+
+	table.startActionPhase()
+	table.EndTurn(players[0].ID)
+	table.EndTurn(players[1].ID)
+
+	if table.CastSkill(string(alchemist.ID), Event{}) != ErrNoActiveSkill {
+		t.Fatal("passive skill should not be cast")
+	}
+
+	// price from the request is ignored
+	table.BuildQuarter(Quarter{Name: "tavern", Price: 0}, string(alchemist.ID))
+	if alchemist.Coins != 2 {
+		t.Fatal("quarter should cost its price")
+	}
+
+	table.EndTurn(alchemist.ID)
+	if alchemist.Coins != 4 {
+		t.Fatal("spent coins should be refunded")
+	}
+	if !hasEvent(drainEvents(players[0]), EventTypeCoinsRefunded) {
+		t.Fatal("refund should be announced")
+	}
+}
+
+// TestArchitect checks extra cards and three builds per turn
+func TestArchitect(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Enchantress(), Architect(), Warlord())
+	architect := players[2]
+	architect.AddCoins(10)
+
+	table.startActionPhase()
+	table.EndTurn(players[0].ID)
+	table.EndTurn(players[1].ID)
+
+	if len(architect.AvailableQuarters) != 2 {
+		t.Fatal("architect should draw two extra cards")
+	}
+	if architect.BuildChancesLeft != 3 {
+		t.Fatal("architect should build up to three quarters")
+	}
+
+	architect.AvailableQuarters = append(architect.AvailableQuarters, table.drawFromDeck(2)...)
+	for i, quarter := range append([]Quarter(nil), architect.AvailableQuarters...) {
+		table.BuildQuarter(quarter, string(architect.ID))
+		if i < 3 && architect.BuildChancesLeft != 2-i {
+			t.Fatal("every build should take one chance, left ", architect.BuildChancesLeft)
+		}
+	}
+	if len(architect.CompletedQuarters) != 3 || len(architect.AvailableQuarters) != 1 {
+		t.Fatal("architect should build exactly three quarters")
+	}
+	if architect.Coins != 7 {
+		t.Fatal("every quarter should be paid")
+	}
+
+	table.EndTurn(architect.ID)
+	warlord := players[3]
+	warlord.AddCoins(2)
+	warlord.AvailableQuarters = table.drawFromDeck(2)
+	for _, quarter := range append([]Quarter(nil), warlord.AvailableQuarters...) {
+		table.BuildQuarter(quarter, string(warlord.ID))
+	}
+	if len(warlord.CompletedQuarters) != 1 || warlord.BuildChancesLeft != 0 {
+		t.Fatal("other heroes should build one quarter")
+	}
+}
+
+// TestWarlord checks destruction rules and Military income
+func TestWarlord(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Abat(), Alchemist(), Warlord())
+	witch, abat, alchemist, warlord := players[0], players[1], players[2], players[3]
+	warlord.CompletedQuarters = []Quarter{{Name: "fortress", Type: QuarterTypeMilitary, Price: 5}}
+	warlord.AddCoins(2)
+	abat.CompletedQuarters = []Quarter{{Name: "temple", Type: QuarterTypeSpiritual, Price: 1}}
+	alchemist.CompletedQuarters = []Quarter{{Name: "castle", Type: QuarterTypeNoble, Price: 4}}
+	for i := 0; i < table.rules.CitySize; i++ {
+		witch.CompletedQuarters = append(witch.CompletedQuarters, table.drawFromDeck(1)...)
+	}
+
+	table.currentIndex = 7
+	table.startActionPhase()
+	if warlord.Coins != 3 {
+		t.Fatal("warlord should take a coin for the Military quarter")
+	}
+
+	cast := func(target *Player, quarter string) error {
+		return table.CastSkill(string(warlord.ID), Event{Data: EventWarlordSkill{TargetID: target.ID, Quarter: quarter}})
+	}
+	if err := cast(witch, witch.CompletedQuarters[0].Name); err != ErrCityComplete {
+		t.Fatal("complete city should be immune, got ", err)
+	}
+	if err := cast(abat, "temple"); err != ErrPlayerProtected {
+		t.Fatal("abat should be protected, got ", err)
+	}
+	if err := cast(warlord, "fortress"); err != ErrCannotCastOnMyself {
+		t.Fatal("warlord should destroy quarters of other players, got ", err)
+	}
+	if err := cast(alchemist, "castle"); err != nil {
+		t.Fatal(err)
+	}
+	if warlord.Coins != 0 || len(alchemist.CompletedQuarters) != 0 {
+		t.Fatal("quarter should be destroyed for its price minus one")
+	}
+	if len(table.discard) != 1 {
+		t.Fatal("destroyed quarter should go to the discard pile")
+	}
+	events := drainEvents(witch)
+	if !hasEvent(events, EventTypeQuarterDestroyed) || !hasEvent(events, EventTypeCoinsPaid) {
+		t.Fatal("destruction should be announced")
+	}
+}
+
+// TestCustomsOfficer checks the tax on quarters built by other players
+func TestCustomsOfficer(t *testing.T) {
+	table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+	alchemist, architect, officer := players[0], players[1], players[3]
+	alchemist.AddCoins(3)
+	alchemist.AvailableQuarters = table.drawFromDeck(1)
+	architect.AddCoins(1)
+	officer.AddCoins(1)
+	officer.AvailableQuarters = table.drawFromDeck(1)
+
+	table.startActionPhase()
+	table.BuildQuarter(alchemist.AvailableQuarters[0], string(alchemist.ID))
+	if alchemist.Coins != 1 || table.customsPool != 1 {
+		t.Fatal("tax should wait on the customs officer card")
+	}
+	if !hasEvent(drainEvents(officer), EventTypeTaxCollected) {
+		t.Fatal("tax should be announced")
+	}
+	table.EndTurn(alchemist.ID)
+	if alchemist.Coins != 2 {
+		t.Fatal("tax should not be refunded by the alchemist")
+	}
+
+	// architect spends his last coin and has nothing to pay
+	table.BuildQuarter(architect.AvailableQuarters[0], string(architect.ID))
+	if len(architect.CompletedQuarters) != 1 || table.customsPool != 1 {
+		t.Fatal("player without coins should not pay the tax")
+	}
+	table.EndTurn(architect.ID)
+	table.EndTurn(players[2].ID)
+
+	if officer.Coins != 2 || table.customsPool != 0 {
+		t.Fatal("customs officer should take the tax when revealed")
+	}
+	table.BuildQuarter(officer.AvailableQuarters[0], string(officer.ID))
+	if officer.Coins != 1 {
+		t.Fatal("customs officer should not pay the tax himself")
+	}
+}
+
+// TestCustomsTaxReturned checks that tax of a customs officer who misses the turn goes back to the bank
+func TestCustomsTaxReturned(t *testing.T) {
+	table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+	alchemist, officer := players[0], players[3]
+	table.noTimers = true
+	alchemist.AddCoins(2)
+	alchemist.AvailableQuarters = table.drawFromDeck(1)
+	table.killedTurn = officer.Heroes[0].Turn
+
+	table.startActionPhase()
+	table.BuildQuarter(alchemist.AvailableQuarters[0], string(alchemist.ID))
+	if alchemist.Coins != 0 || table.customsPool != 1 {
+		t.Fatal("tax should be collected before the customs officer is revealed")
+	}
+	drainEvents(officer)
+	table.EndTurn(alchemist.ID)
+	table.EndTurn(players[1].ID)
+	table.EndTurn(players[2].ID)
+
+	if officer.Coins != 0 || table.customsPool != 0 {
+		t.Fatal("killed customs officer should not take the tax")
+	}
+	if !hasEvent(drainEvents(officer), EventTypeTaxReturned) {
+		t.Fatal("tax should be returned to the bank at round end")
+	}
+}
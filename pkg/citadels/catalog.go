@@ -0,0 +1,65 @@
+package citadels
+
+// CatalogEntry is a kind of quarter and number of its copies in the deck
+type CatalogEntry struct {
+	Quarter Quarter `json:"quarter"`
+	Count   int     `json:"count"`
+}
+
+// Catalog describes all quarters of the deck,
+// tables can play with different catalogs
+type Catalog struct {
+	ID      string         `json:"id"`
+	Entries []CatalogEntry `json:"entries"`
+}
+
+// Deck returns unshuffled deck with every copy of every quarter
+func (c Catalog) Deck() []Quarter {
+	deck := make([]Quarter, 0)
+	for _, entry := range c.Entries {
+		for i := 0; i < entry.Count; i++ {
+			deck = append(deck, entry.Quarter)
+		}
+	}
+	return deck
+}
+
+// ClassicCatalog is the district deck of the original game
+func ClassicCatalog() Catalog {
+	return Catalog{
+		ID: "classic",
+		Entries: []CatalogEntry{
+			{Quarter{Name: "Manor", Type: QuarterTypeNoble, Price: 3}, 5},
+			{Quarter{Name: "Castle", Type: QuarterTypeNoble, Price: 4}, 4},
+			{Quarter{Name: "Palace", Type: QuarterTypeNoble, Price: 5}, 3},
+
+			{Quarter{Name: "Temple", Type: QuarterTypeSpiritual, Price: 1}, 3},
+			{Quarter{Name: "Church", Type: QuarterTypeSpiritual, Price: 2}, 3},
+			{Quarter{Name: "Monastery", Type: QuarterTypeSpiritual, Price: 3}, 3},
+			{Quarter{Name: "Cathedral", Type: QuarterTypeSpiritual, Price: 5}, 2},
+
+			{Quarter{Name: "Tavern", Type: QuarterTypeTrade, Price: 1}, 5},
+			{Quarter{Name: "Market", Type: QuarterTypeTrade, Price: 2}, 4},
+			{Quarter{Name: "Trading Post", Type: QuarterTypeTrade, Price: 2}, 3},
+			{Quarter{Name: "Docks", Type: QuarterTypeTrade, Price: 3}, 3},
+			{Quarter{Name: "Harbor", Type: QuarterTypeTrade, Price: 4}, 3},
+			{Quarter{Name: "Town Hall", Type: QuarterTypeTrade, Price: 5}, 2},
+
+			{Quarter{Name: "Watchtower", Type: QuarterTypeMilitary, Price: 1}, 3},
+			{Quarter{Name: "Prison", Type: QuarterTypeMilitary, Price: 2}, 3},
+			{Quarter{Name: "Battlefield", Type: QuarterTypeMilitary, Price: 3}, 3},
+			{Quarter{Name: "Fortress", Type: QuarterTypeMilitary, Price: 5}, 2},
+
+			{Quarter{Name: "Haunted Quarter", Type: QuarterTypeSpecial, Price: 2}, 1},
+			{Quarter{Name: "Keep", Type: QuarterTypeSpecial, Price: 3}, 1},
+			{Quarter{Name: "Laboratory", Type: QuarterTypeSpecial, Price: 5}, 1},
+			{Quarter{Name: "Smithy", Type: QuarterTypeSpecial, Price: 5}, 1},
+			{Quarter{Name: "Observatory", Type: QuarterTypeSpecial, Price: 5}, 1},
+			{Quarter{Name: "Graveyard", Type: QuarterTypeSpecial, Price: 5}, 1},
+			{Quarter{Name: "School of Magic", Type: QuarterTypeSpecial, Price: 6}, 1},
+			{Quarter{Name: "Library", Type: QuarterTypeSpecial, Price: 6}, 1},
+			{Quarter{Name: "Great Wall", Type: QuarterTypeSpecial, Price: 6}, 1},
+			{Quarter{Name: "Dragon Gate", Type: QuarterTypeSpecial, Price: 6}, 1},
+		},
+	}
+}
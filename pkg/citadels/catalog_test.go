@@ -0,0 +1,47 @@
+package citadels
+
+import "testing"
+
+// TestCatalogDeck checks that the deck has every copy of every quarter
+func TestCatalogDeck(t *testing.T) {
+	catalog := ClassicCatalog()
+	deck := catalog.Deck()
+
+	var total int
+	for _, entry := range catalog.Entries {
+		total += entry.Count
+	}
+	if len(deck) != total {
+		t.Fatal("deck should contain every copy, got ", len(deck))
+	}
+
+	counts := make(map[string]int)
+	for _, quarter := range deck {
+		counts[quarter.Name]++
+	}
+	if counts["Tavern"] != 5 || counts["Cathedral"] != 2 || counts["Keep"] != 1 {
+		t.Fatal("wrong number of copies")
+	}
+}
+
+// TestTableCatalog checks that a table builds its deck from its own catalog
+func TestTableCatalog(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Emperor(), Warlord(), CustomsOfficer())
+	table.started = false
+	err := table.SetCatalog(Catalog{ID: "taverns", Entries: []CatalogEntry{
+		{Quarter: Quarter{Name: "Tavern", Type: QuarterTypeTrade, Price: 1}, Count: 20},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	table.drawCards()
+	if len(table.deck) != 20-4*len(players) {
+		t.Fatal("every player should draw four cards from the deck")
+	}
+	for _, quarter := range append(table.deck, players[0].AvailableQuarters...) {
+		if quarter.Name != "Tavern" {
+			t.Fatal("deck should be made of the table catalog")
+		}
+	}
+}
@@ -9,12 +9,21 @@ import (
 
 // TestTable tests game cycle inside Table
 func TestTable(t *testing.T) {
-	table := NewTable(false)
+	table := newTable(t, HeroSetDefault)
 	//done := make(chan struct{})
 	wg := sync.WaitGroup{}
 	wg.Add(4)
 	onEv := func(logging bool) OnEventFunc {
 		return func(e Event, p *Player) {
+			// events reach players as json
+			b, err := EncodeEvent(e)
+			if err != nil {
+				t.Fatal(err)
+			}
+			e, err = DecodeEvent(b)
+			if err != nil {
+				t.Fatal(err)
+			}
 			if logging {
 				t.Log(e.Type)
 			}
@@ -33,13 +42,15 @@ func TestTable(t *testing.T) {
 				if data.PlayerID == p.ID {
 					if len(p.AvailableQuarters) < 1 && p.Coins > 0 {
 						table.MakeAction(ActionTypeCards, string(p.ID))
+						// Library gives cards without a choice
+						if len(p.currentCardsChoice) > 0 {
+							return
+						}
 					} else {
 						table.MakeAction(ActionTypeCoin, string(p.ID))
 					}
-					if p.Coins > 0 && len(p.AvailableQuarters) > 0 {
-						table.BuildQuarter(p.AvailableQuarters[0], string(p.ID))
-						table.EndTurn(p.ID)
-					}
+					buildAffordable(table, p)
+					table.EndTurn(p.ID)
 				}
 			case EventTypeChooseCards:
 				data, ok := e.Data.(EventChooseCards)
@@ -47,11 +58,9 @@ func TestTable(t *testing.T) {
 					t.Fatal("wrong event")
 				}
 				table.SelectCard(data.Cards[0].Name, string(p.ID))
-				if p.Coins > 0 && len(p.AvailableQuarters) > 0 {
-					table.BuildQuarter(p.AvailableQuarters[0], string(p.ID))
-					if logging {
-						t.Log(len(p.AvailableQuarters))
-					}
+				buildAffordable(table, p)
+				if logging {
+					t.Log(len(p.AvailableQuarters))
 				}
 				table.EndTurn(p.ID)
 			case EventTypeGameEnded:
@@ -96,3 +105,140 @@ func TestTable(t *testing.T) {
 
 	wg.Wait()
 }
+
+// newTable returns table with official rules
+func newTable(t *testing.T, heroSet string) *Table {
+	table, err := NewTable(TableOptions{HeroSet: heroSet})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+// buildAffordable builds the first quarter from the hand that player can build
+func buildAffordable(table *Table, p *Player) {
+	for _, quarter := range p.AvailableQuarters {
+		if quarter.Price <= p.Coins && !p.builtQuarter(quarter.Name) {
+			table.BuildQuarter(quarter, string(p.ID))
+			return
+		}
+	}
+}
+
+// newTestTable returns started table where i-th player holds i-th hero,
+// the first player is the king and the deck is filled with cheap quarters,
+// queues of events are small so tables of finished tests do not hold much memory
+func newTestTable(t *testing.T, heroes ...Hero) (*Table, []*Player) {
+	table := newTable(t, HeroSetDefault)
+
+	players := make([]*Player, len(heroes))
+	for i, hero := range heroes {
+		p := newPlayer(PlayerID("p"+strconv.Itoa(i+1)), func(Event, *Player) {}, 10000)
+		err := table.AddPlayer(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p.Heroes = []Hero{hero}
+		players[i] = p
+	}
+	table.king = players[0]
+	table.started = true
+
+	types := []string{
+		QuarterTypeMilitary, QuarterTypeSpecial, QuarterTypeNoble, QuarterTypeSpiritual, QuarterTypeTrade,
+	}
+	for i := 0; i < 50; i++ {
+		table.deck = append(table.deck, Quarter{
+			Name:  "quarter" + strconv.Itoa(i),
+			Type:  types[i%len(types)],
+			Price: 1,
+		})
+	}
+	return table, players
+}
+
+// drainEvents returns all events that player received so far
+func drainEvents(p *Player) []Event {
+	events := make([]Event, 0)
+	for {
+		select {
+		case e, ok := <-p.updates:
+			if !ok {
+				return events
+			}
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+// hasEvent reports whether events contain event with given type
+func hasEvent(events []Event, eventType string) bool {
+	for _, e := range events {
+		if e.Type == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// TestCastKeepsTurn checks that the caster goes on with the turn after the skill
+func TestCastKeepsTurn(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Blackmailer(), Emperor(), Warlord())
+	emperor, warlord := players[2], players[3]
+	table.noTimers = true
+	warlord.AddCoins(1)
+	emperor.AddCoins(1)
+	emperor.AvailableQuarters = table.drawFromDeck(1)
+
+	table.currentIndex = EmperorTurn - 1
+	table.startActionPhase()
+	err := table.CastSkill(string(emperor.ID), Event{Data: EventEmperorSkill{TargetID: warlord.ID, Coin: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Turn().ID != emperor.ID {
+		t.Fatal("cast should not end the turn")
+	}
+
+	table.MakeAction(ActionTypeCoin, string(emperor.ID))
+	table.BuildQuarter(emperor.AvailableQuarters[0], string(emperor.ID))
+	if len(emperor.CompletedQuarters) != 1 {
+		t.Fatal("caster should build after the skill")
+	}
+	err = table.CastSkill(string(emperor.ID), Event{Data: EventEmperorSkill{TargetID: players[0].ID}})
+	if err != ErrSkillAlreadyUsed {
+		t.Fatal("skill should be cast once a turn, got ", err)
+	}
+
+	table.EndTurn(emperor.ID)
+	if table.Turn().ID != warlord.ID {
+		t.Fatal("turn should end when the caster ends it")
+	}
+}
+
+// TestEnded checks that the end of the game is seen by every listener
+func TestEnded(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Blackmailer(), Emperor(), Warlord())
+	table.noTimers = true
+	players[0].CompletedQuarters = table.drawFromDeck(table.rules.CitySize)
+	table.completedQuartersFirst = players[0]
+
+	table.startActionPhase()
+	for table.currentPhase == ActionPhase {
+		table.EndTurn(table.Turn().ID)
+	}
+
+	for i := 0; i < 2; i++ {
+		select {
+		case <-table.Ended():
+		default:
+			t.Fatal("table should be ended for every listener")
+		}
+	}
+	drainEvents(players[0])
+	if _, ok := <-players[0].updates; ok {
+		t.Fatal("updates of players should be closed")
+	}
+}
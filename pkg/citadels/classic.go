@@ -0,0 +1,222 @@
+package citadels
+
+// KingTurn is turn of the King in classic hero set
+const KingTurn = 4
+
+// Assassin kills a hero, the killed hero misses the turn
+func Assassin() Hero {
+	return Hero{
+		Name: "Assassin",
+		Turn: 1,
+		Skill: Skill{
+			Type:    SkillTypeAnytime,
+			Payload: EventTargetHero{},
+			Do: func(t *Table, caster *Player, ev Event) error {
+				var e EventTargetHero
+				err := decodeEventData(ev, &e)
+				if err != nil {
+					return err
+				}
+
+				hero, ok := t.heroByName(e.HeroName)
+				if !ok {
+					return ErrHeroNotExists
+				}
+				if hero.Turn == t.currentIndex {
+					return ErrCannotCastOnMyself
+				}
+				if hero.Turn < t.currentIndex {
+					return ErrHeroAlreadyPlayed
+				}
+
+				t.killedTurn = hero.Turn
+				t.doBroadcastEvent(Event{
+					Type: EventTypeHeroKilled,
+					Data: EventHeroTargeted{
+						PlayerID: caster.ID,
+						HeroName: hero.Name,
+					},
+				})
+				return nil
+			},
+			Targets: func(t *Table, caster *Player) []interface{} {
+				targets := make([]interface{}, 0)
+				for _, hero := range t.heroesAfter(t.currentIndex) {
+					targets = append(targets, EventTargetHero{HeroName: hero.Name})
+				}
+				return targets
+			},
+		},
+	}
+}
+
+// Thief robs a hero, all coins of robbed hero's player go to the Thief when the hero is revealed
+func Thief() Hero {
+	return Hero{
+		Name: "Thief",
+		Turn: 2,
+		Skill: Skill{
+			Type:    SkillTypeAnytime,
+			Payload: EventTargetHero{},
+			Do: func(t *Table, caster *Player, ev Event) error {
+				var e EventTargetHero
+				err := decodeEventData(ev, &e)
+				if err != nil {
+					return err
+				}
+
+				hero, ok := t.heroByName(e.HeroName)
+				if !ok {
+					return ErrHeroNotExists
+				}
+				if hero.Turn == t.currentIndex {
+					return ErrCannotCastOnMyself
+				}
+				if hero.Turn < t.currentIndex {
+					return ErrHeroAlreadyPlayed
+				}
+				if hero.Turn == t.killedTurn {
+					return ErrHeroKilled
+				}
+
+				t.thief = caster
+				t.robbedTurn = hero.Turn
+				t.doBroadcastEvent(Event{
+					Type: EventTypeHeroRobbed,
+					Data: EventHeroTargeted{
+						PlayerID: caster.ID,
+						HeroName: hero.Name,
+					},
+				})
+				return nil
+			},
+			Targets: func(t *Table, caster *Player) []interface{} {
+				targets := make([]interface{}, 0)
+				for _, hero := range t.heroesAfter(t.currentIndex) {
+					if hero.Turn != t.killedTurn {
+						targets = append(targets, EventTargetHero{HeroName: hero.Name})
+					}
+				}
+				return targets
+			},
+		},
+	}
+}
+
+// rob gives all coins of robbed player to the Thief
+func (t *Table) rob(p *Player) {
+	count := p.Coins
+	p.AddCoins(-count)
+	t.thief.AddCoins(count)
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeStealCoin,
+		Data: EventSteal{
+			FromID: p.ID,
+			To:     t.thief.ID,
+			Count:  count,
+		},
+	})
+}
+
+// Magician swaps his hand with another player or exchanges cards with the deck
+func Magician() Hero {
+	hero := Enchantress()
+	hero.Name = "Magician"
+	return hero
+}
+
+// King takes the crown when revealed and a coin for every Noble quarter,
+// killed King takes the crown at the end of the round
+func King() Hero {
+	return Hero{
+		Name: "King",
+		Turn: KingTurn,
+		Skill: Skill{
+			Type: SkillTypePassive,
+			OnTurnStart: func(t *Table, p *Player) {
+				if t.king.ID != p.ID {
+					t.moveCrown(p)
+				}
+				t.takeIncome(p, QuarterTypeNoble)
+			},
+			OnRoundEnd: func(t *Table, p *Player) {
+				if p != nil && t.killedTurn == KingTurn && t.king.ID != p.ID {
+					t.moveCrown(p)
+				}
+			},
+		},
+	}
+}
+
+// Bishop takes a coin for every Spiritual quarter, his quarters are protected from the Warlord
+func Bishop() Hero {
+	return Hero{
+		Name:      "Bishop",
+		Turn:      5,
+		Protected: true,
+		Skill: Skill{
+			Type: SkillTypePassive,
+			OnTurnStart: func(t *Table, p *Player) {
+				t.takeIncome(p, QuarterTypeSpiritual)
+			},
+		},
+	}
+}
+
+// Merchant takes an extra coin and a coin for every Trade quarter
+func Merchant() Hero {
+	return Hero{
+		Name: "Merchant",
+		Turn: 6,
+		Skill: Skill{
+			Type: SkillTypePassive,
+			OnTurnStart: func(t *Table, p *Player) {
+				p.AddCoins(1)
+				t.doBroadcastEvent(Event{
+					Type: EventTypeCoinsGive,
+					Data: EventCoinGive{
+						To:     p.ID,
+						Amount: 1,
+						Sum:    p.Coins,
+					},
+				})
+				t.takeIncome(p, QuarterTypeTrade)
+			},
+		},
+	}
+}
+
+// Queen takes three coins if she sits next to the King
+func Queen() Hero {
+	return Hero{
+		Name: "Queen",
+		Turn: 9,
+		Skill: Skill{
+			Type: SkillTypePassive,
+			OnTurnStart: func(t *Table, p *Player) {
+				king, ok := t.heroByName("King")
+				if !ok {
+					return
+				}
+				holder := t.heroHolder(king)
+				if holder == nil || holder.ID == p.ID {
+					return
+				}
+				if t.playerAfter(p).ID != holder.ID && t.playerAfter(holder).ID != p.ID {
+					return
+				}
+
+				p.AddCoins(3)
+				t.doBroadcastEvent(Event{
+					Type: EventTypeCoinsGive,
+					Data: EventCoinGive{
+						To:     p.ID,
+						Amount: 3,
+						Sum:    p.Coins,
+					},
+				})
+			},
+		},
+	}
+}
@@ -0,0 +1,166 @@
+package citadels
+
+import "testing"
+
+// newClassicTable returns test table which plays the classic hero set, i-th player holds i-th hero
+func newClassicTable(t *testing.T, heroes ...Hero) (*Table, []*Player) {
+	table, players := newTestTable(t, heroes...)
+	table.heroSet = HeroSetClassic
+	table.heroes, _ = HeroSetByID(HeroSetClassic)
+	table.noTimers = true
+	return table, players
+}
+
+// TestClassicRound drives a round of the classic hero set
+func TestClassicRound(t *testing.T) {
+	table, players := newClassicTable(t, Assassin(), Thief(), King(), Merchant())
+	assassin, thief, king, merchant := players[0], players[1], players[2], players[3]
+	king.CompletedQuarters = []Quarter{{Name: "palace", Type: QuarterTypeNoble, Price: 5}}
+	merchant.CompletedQuarters = []Quarter{{Name: "market", Type: QuarterTypeTrade, Price: 2}}
+	merchant.AddCoins(3)
+
+	table.startActionPhase()
+	err := table.CastSkill(string(assassin.ID), Event{Data: EventTargetHero{HeroName: "King"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table.EndTurn(assassin.ID)
+
+	err = table.CastSkill(string(thief.ID), Event{Data: EventTargetHero{HeroName: "King"}})
+	if err != ErrHeroKilled {
+		t.Fatal("killed hero should not be robbed, got ", err)
+	}
+	err = table.CastSkill(string(thief.ID), Event{Data: EventTargetHero{HeroName: "Merchant"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table.EndTurn(thief.ID)
+
+	if table.Turn().ID != merchant.ID {
+		t.Fatal("killed king should miss the turn")
+	}
+	if !hasEvent(drainEvents(assassin), EventTypeKilledHeroSkipped) {
+		t.Fatal("skipped turn should be announced")
+	}
+	if thief.Coins != 3 {
+		t.Fatal("thief should take coins of the merchant")
+	}
+	if merchant.Coins != 2 {
+		t.Fatal("merchant should take an extra coin and a coin for the Trade quarter")
+	}
+
+	table.EndTurn(merchant.ID)
+	if table.King().ID != king.ID {
+		t.Fatal("killed king should take the crown at the end of the round")
+	}
+	if king.Coins != 0 {
+		t.Fatal("killed king should not take income")
+	}
+}
+
+// TestClassicTargets checks which heroes the Assassin and the Thief can not target
+func TestClassicTargets(t *testing.T) {
+	table, players := newClassicTable(t, Assassin(), Thief(), King(), Merchant())
+	assassin, thief := players[0], players[1]
+	table.startActionPhase()
+
+	if err := table.CastSkill(string(assassin.ID), Event{Data: EventTargetHero{HeroName: "Assassin"}}); err != ErrCannotCastOnMyself {
+		t.Fatal("assassin should not kill himself, got ", err)
+	}
+	table.CastSkill(string(assassin.ID), Event{Data: EventTargetHero{HeroName: "Merchant"}})
+	table.EndTurn(assassin.ID)
+
+	if err := table.CastSkill(string(thief.ID), Event{Data: EventTargetHero{HeroName: "Assassin"}}); err != ErrHeroAlreadyPlayed {
+		t.Fatal("hero who already played should not be robbed, got ", err)
+	}
+	err := table.Apply(thief.ID, CastSkillCommand{Data: EventTargetHero{HeroName: "Merchant"}})
+	if ErrorTypeOf(err) != ErrorTypeHeroKilled {
+		t.Fatal("killed hero should not be robbed, got ", err)
+	}
+}
+
+// TestBishop checks Spiritual income and protection from the Warlord which the killed Bishop loses
+func TestBishop(t *testing.T) {
+	table, players := newClassicTable(t, Bishop(), Merchant(), Warlord(), Thief())
+	bishop, warlord := players[0], players[2]
+	bishop.CompletedQuarters = []Quarter{
+		{Name: "temple", Type: QuarterTypeSpiritual, Price: 1},
+		{Name: "church", Type: QuarterTypeSpiritual, Price: 2},
+		{Name: "castle", Type: QuarterTypeNoble, Price: 4},
+	}
+	warlord.AddCoins(5)
+
+	table.currentIndex = 4
+	table.startActionPhase()
+	if bishop.Coins != 2 {
+		t.Fatal("bishop should take a coin for every Spiritual quarter, got ", bishop.Coins)
+	}
+
+	table.currentIndex = 7
+	table.startActionPhase()
+	destroy := func() error {
+		return table.CastSkill(string(warlord.ID), Event{Data: EventWarlordSkill{TargetID: bishop.ID, Quarter: "castle"}})
+	}
+	if err := destroy(); err != ErrPlayerProtected {
+		t.Fatal("city of the bishop should be protected, got ", err)
+	}
+
+	table.killedTurn = Bishop().Turn
+	if err := destroy(); err != nil {
+		t.Fatal("killed bishop should lose the protection, got ", err)
+	}
+}
+
+// TestQueen checks that the Queen takes three coins only next to the King's player
+func TestQueen(t *testing.T) {
+	tests := []struct {
+		name   string
+		heroes []Hero
+		coins  int
+	}{
+		{"next to the king", []Hero{Assassin(), King(), Queen(), Merchant()}, 3},
+		{"far from the king", []Hero{King(), Assassin(), Queen(), Merchant()}, 0},
+	}
+	for _, tt := range tests {
+		table, players := newClassicTable(t, tt.heroes...)
+		queen := players[2]
+		table.currentIndex = 8
+		table.startActionPhase()
+		if table.Turn().ID != queen.ID || queen.Coins != tt.coins {
+			t.Fatalf("%s: queen got %d coins, want %d", tt.name, queen.Coins, tt.coins)
+		}
+	}
+}
+
+// TestMagician checks that the Magician swaps hands or exchanges cards with the deck
+func TestMagician(t *testing.T) {
+	table, players := newClassicTable(t, Assassin(), Thief(), Magician(), King())
+	magician, king := players[2], players[3]
+	magician.AvailableQuarters = []Quarter{{Name: "tower", Price: 1}, {Name: "gate", Price: 1}}
+	king.AvailableQuarters = []Quarter{{Name: "palace", Price: 5}}
+	table.currentIndex = 2
+	table.startActionPhase()
+
+	err := table.CastSkill(string(magician.ID), Event{Data: EventEnchantressSkill{Swap: &EventSwapHands{TargetID: king.ID}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(magician.AvailableQuarters) != 1 || len(king.AvailableQuarters) != 2 {
+		t.Fatal("magician should swap hands with the king")
+	}
+
+	table, players = newClassicTable(t, Assassin(), Thief(), Magician(), King())
+	magician = players[2]
+	magician.AvailableQuarters = []Quarter{{Name: "tower", Price: 1}, {Name: "gate", Price: 1}}
+	table.currentIndex = 2
+	table.startActionPhase()
+	deck := len(table.deck)
+
+	err = table.CastSkill(string(magician.ID), Event{Data: EventEnchantressSkill{Exchange: &EventExchangeCards{Cards: []string{"tower"}}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, kept := magician.quarterInHand("tower"); len(magician.AvailableQuarters) != 2 || kept || len(table.deck) != deck {
+		t.Fatal("magician should exchange the card with the deck")
+	}
+}
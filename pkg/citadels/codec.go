@@ -0,0 +1,265 @@
+package citadels
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+var (
+	eventsMu      sync.RWMutex
+	eventPayloads map[string]reflect.Type
+
+	// skillPayloads are types of data of CastSkill events by name of the hero
+	skillPayloads = make(map[string]reflect.Type)
+)
+
+func init() {
+	// nil payload means the event carries no data
+	payloads := map[string]interface{}{
+		EventTypeGameStarted:           EventGameStarted{},
+		EventTypePickPhaseStarted:      EventPickPhaseStarted{},
+		EventTypeActionPhaseStarted:    nil,
+		EventTypeNextSelecting:         EventPlayerID{},
+		EventTypeCastSkill:             EventCastSkill{},
+		EventTypeStealCoin:             EventSteal{},
+		EventTypeStealCard:             EventSteal{},
+		EventTypeStealCoinPrivate:      EventSteal{},
+		EventTypeStealCardPrivate:      EventStealCards{},
+		EventTypeHeroSelected:          EventHeroSelected{},
+		EventTypeChooseHero:            EventChooseHero{},
+		EventTypeChooseDiscard:         EventChooseHero{},
+		EventTypeHeroDiscarded:         EventHeroSelected{},
+		EventTypePlayerDiscardedHero:   EventPlayerID{},
+		EventTypeNextTurn:              EventNextTurn{},
+		EventTypeHeroIsAbsent:          EventHeroIsAbsent{},
+		EventTypeRevealHeroSet:         EventHeroSet{},
+		EventTypeCoinsGive:             EventCoinGive{},
+		EventTypePlayerChoosingCards:   EventPlayerChoosingCards{},
+		EventTypeChooseCards:           EventChooseCards{},
+		EventTypePlayerSelectedCard:    EventPlayerSelectedCard{},
+		EventTypeDrawCards:             EventCards{},
+		EventTypePlayerBuiltQuarter:    EventQuarter{},
+		EventTypeGameEnded:             EventGameEnded{},
+		EventTypeHeroBewitched:         EventHeroBewitched{},
+		EventTypePlayerBewitched:       EventPlayerBewitched{},
+		EventTypeWitchTakesTurn:        EventWitchTakesTurn{},
+		EventTypeThreatsPlaced:         EventThreats{},
+		EventTypeThreatsPlacedPrivate:  EventThreats{},
+		EventTypePlayerThreatened:      EventPlayerID{},
+		EventTypeChooseThreatPayment:   EventThreatPayment{},
+		EventTypeThreatPaid:            EventSteal{},
+		EventTypeThreatRefused:         EventPlayerID{},
+		EventTypeChooseThreatReveal:    EventPlayerID{},
+		EventTypeThreatRevealed:        EventThreatRevealed{},
+		EventTypeHandsSwapped:          EventHandsSwapped{},
+		EventTypeHandsSwappedPrivate:   EventHandsSwappedCards{},
+		EventTypeCardsExchanged:        EventCardsExchanged{},
+		EventTypeCardsExchangedPrivate: EventCards{},
+		EventTypeCrownMoved:            EventCrownMoved{},
+		EventTypeIncomeCollected:       EventIncome{},
+		EventTypeTithePaid:             EventSteal{},
+		EventTypeCoinsRefunded:         EventCoinGive{},
+		EventTypePlayerDrewCards:       EventPlayerDrewCards{},
+		EventTypeQuarterDestroyed:      EventQuarterDestroyed{},
+		EventTypeCoinsPaid:             EventCoinsPaid{},
+		EventTypeTaxCollected:          EventTax{},
+		EventTypeTaxReturned:           EventTax{},
+		EventTypeHeroKilled:            EventHeroTargeted{},
+		EventTypeKilledHeroSkipped:     EventHeroIsAbsent{},
+		EventTypeHeroRobbed:            EventHeroTargeted{},
+		EventTypeQuarterUsed:           EventQuarterUsed{},
+		EventTypeQuarterSalvaged:       EventQuarterDestroyed{},
+		EventTypeChooseSalvage:         EventQuarterDestroyed{},
+		EventTypeSalvageDeclined:       EventQuarterDestroyed{},
+	}
+
+	eventPayloads = make(map[string]reflect.Type, len(payloads))
+	for eventType, payload := range payloads {
+		eventPayloads[eventType] = reflect.TypeOf(payload)
+	}
+}
+
+// RegisterEvent makes the codec decode data of events with the type into the type of payload,
+// payload is a zero value of the struct or nil if the event has no data
+func RegisterEvent(eventType string, payload interface{}) {
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	eventPayloads[eventType] = reflect.TypeOf(payload)
+}
+
+// RegisterSkillPayload makes the codec decode data of CastSkill events of the hero into the type of payload
+func RegisterSkillPayload(hero string, payload interface{}) {
+	eventsMu.Lock()
+	defer eventsMu.Unlock()
+	skillPayloads[hero] = reflect.TypeOf(payload)
+}
+
+// registerSkillPayloads registers data of skills of heroes which take data
+func registerSkillPayloads(heroes []Hero) {
+	for _, hero := range heroes {
+		if hero.Skill.Payload != nil {
+			RegisterSkillPayload(hero.Name, hero.Skill.Payload)
+		}
+	}
+}
+
+// skillPayloadConflict returns name of the hero whose skill takes other data than the skill
+// registered under the same name, heroes of all sets share the data by name
+func skillPayloadConflict(sets map[string][]Hero) (string, bool) {
+	eventsMu.RLock()
+	defer eventsMu.RUnlock()
+
+	seen := make(map[string]reflect.Type)
+	for _, heroes := range sets {
+		for _, hero := range heroes {
+			if hero.Skill.Payload == nil {
+				continue
+			}
+			payload := reflect.TypeOf(hero.Skill.Payload)
+			if known, ok := skillPayloads[hero.Name]; ok && known != payload {
+				return hero.Name, true
+			}
+			if known, ok := seen[hero.Name]; ok && known != payload {
+				return hero.Name, true
+			}
+			seen[hero.Name] = payload
+		}
+	}
+	return "", false
+}
+
+// UnmarshalJSON decodes data of the skill into the payload registered for the hero
+func (e *EventCastSkill) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		Hero string          `json:"hero"`
+		Data json.RawMessage `json:"data"`
+	}
+	err := decodeStrict(data, &raw)
+	if err != nil {
+		return err
+	}
+
+	eventsMu.RLock()
+	payload, ok := skillPayloads[raw.Hero]
+	eventsMu.RUnlock()
+	if !ok {
+		return fmt.Errorf("skill of %q: %w", raw.Hero, ErrWrongEventData)
+	}
+
+	v := reflect.New(payload)
+	err = decodeStrict(raw.Data, v.Interface())
+	if err != nil {
+		return err
+	}
+	e.Hero = raw.Hero
+	e.Data = v.Elem().Interface()
+	return nil
+}
+
+// payloadOf returns type of data of events with the type
+func payloadOf(eventType string) (reflect.Type, bool) {
+	eventsMu.RLock()
+	defer eventsMu.RUnlock()
+	payload, ok := eventPayloads[eventType]
+	return payload, ok
+}
+
+// EventError tells why the event can not be decoded
+type EventError struct {
+	// Type is type of the event, empty if the event itself is not json
+	Type string
+
+	// Err is ErrUnknownEventType or ErrWrongEventData
+	Err error
+
+	// Cause is the error of json if there is one
+	Cause error
+}
+
+func (e *EventError) Error() string {
+	msg := e.Err.Error()
+	if e.Type != "" {
+		msg = fmt.Sprintf("event %s: %s", e.Type, msg)
+	}
+	if e.Cause != nil {
+		msg = fmt.Sprintf("%s: %v", msg, e.Cause)
+	}
+	return msg
+}
+
+func (e *EventError) Unwrap() error {
+	return e.Err
+}
+
+// EncodeEvent returns event as json
+func EncodeEvent(e Event) ([]byte, error) {
+	if e.Type != "" {
+		if _, ok := payloadOf(e.Type); !ok {
+			return nil, &EventError{Type: e.Type, Err: ErrUnknownEventType}
+		}
+	}
+	return json.Marshal(e)
+}
+
+// DecodeEvent parses json of the event, Event.Data gets the struct registered for its type.
+// Events without type are errors sent to the player and carry no data
+func DecodeEvent(data []byte) (Event, error) {
+	var raw struct {
+		Type  string          `json:"type"`
+		Data  json.RawMessage `json:"data"`
+		Error string          `json:"error"`
+	}
+	err := json.Unmarshal(data, &raw)
+	if err != nil {
+		return Event{}, &EventError{Err: ErrWrongEventData, Cause: err}
+	}
+
+	e := Event{Type: raw.Type, Error: raw.Error}
+	if raw.Type == "" {
+		if raw.Error == "" {
+			return Event{}, &EventError{Err: ErrUnknownEventType}
+		}
+		if !isNull(raw.Data) {
+			return Event{}, &EventError{Err: ErrWrongEventData}
+		}
+		return e, nil
+	}
+
+	payload, ok := payloadOf(raw.Type)
+	if !ok {
+		return Event{}, &EventError{Type: raw.Type, Err: ErrUnknownEventType}
+	}
+	if payload == nil {
+		if !isNull(raw.Data) {
+			return Event{}, &EventError{Type: raw.Type, Err: ErrWrongEventData}
+		}
+		return e, nil
+	}
+	if isNull(raw.Data) {
+		return Event{}, &EventError{Type: raw.Type, Err: ErrWrongEventData}
+	}
+
+	v := reflect.New(payload)
+	err = decodeStrict(raw.Data, v.Interface())
+	if err != nil {
+		return Event{}, &EventError{Type: raw.Type, Err: ErrWrongEventData, Cause: err}
+	}
+	e.Data = v.Elem().Interface()
+	return e, nil
+}
+
+// decodeStrict parses json into v, unknown fields are errors
+func decodeStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+// isNull reports whether raw json is absent or null
+func isNull(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) == 0 || bytes.Equal(raw, []byte("null"))
+}
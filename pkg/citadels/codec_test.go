@@ -0,0 +1,209 @@
+package citadels
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestDecodeRecordedEvents decodes every event of a played game
+func TestDecodeRecordedEvents(t *testing.T) {
+	log := playRecorded(t)
+
+	var decoded int
+	scanner := bufio.NewScanner(log)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var rec Record
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			t.Fatal(err)
+		}
+		if rec.Event == nil {
+			continue
+		}
+
+		data, err := json.Marshal(struct {
+			Type  string          `json:"type"`
+			Data  json.RawMessage `json:"data"`
+			Error string          `json:"error"`
+		}{rec.Event.Type, rec.Event.Data, rec.Event.Error})
+		if err != nil {
+			t.Fatal(err)
+		}
+		e, err := DecodeEvent(data)
+		if err != nil {
+			t.Fatalf("record %d: %v", rec.Seq, err)
+		}
+
+		if e.Type != "" {
+			payload, _ := payloadOf(e.Type)
+			if payload != nil && reflect.TypeOf(e.Data) != payload {
+				t.Fatalf("%s: got %T, want %v", e.Type, e.Data, payload)
+			}
+		}
+		encoded, err := EncodeEvent(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		again, err := DecodeEvent(encoded)
+		if err != nil || !reflect.DeepEqual(eventJSON(again), eventJSON(e)) {
+			t.Fatalf("%s does not survive encoding: %v", e.Type, err)
+		}
+		decoded++
+	}
+	if decoded == 0 {
+		t.Fatal("no events in the log")
+	}
+}
+
+// eventJSON returns json of the event, heroes have skills with functions which DeepEqual can not compare
+func eventJSON(e Event) string {
+	b, _ := json.Marshal(e)
+	return string(b)
+}
+
+func TestDecodeEventErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		typ  string
+		err  error
+	}{
+		{"not json", `{"type":`, "", ErrWrongEventData},
+		{"no type", `{"data":{}}`, "", ErrUnknownEventType},
+		{"unknown type", `{"type":"Dance","data":{}}`, "Dance", ErrUnknownEventType},
+		{"wrong field", `{"type":"NextSelecting","data":{"player_id":5}}`, EventTypeNextSelecting, ErrWrongEventData},
+		{"unknown field", `{"type":"CrownMoved","data":{"from":"a","to":"b","by":"c"}}`, EventTypeCrownMoved, ErrWrongEventData},
+		{"no data", `{"type":"CrownMoved"}`, EventTypeCrownMoved, ErrWrongEventData},
+		{"unexpected data", `{"type":"ActionPhaseStarted","data":{"a":1}}`, EventTypeActionPhaseStarted, ErrWrongEventData},
+		{"error with data", `{"error":"errors.wrong.action","data":{"a":1}}`, "", ErrWrongEventData},
+	}
+	for _, tt := range tests {
+		_, err := DecodeEvent([]byte(tt.data))
+		if !errors.Is(err, tt.err) {
+			t.Errorf("%s: got %v, want %v", tt.name, err, tt.err)
+			continue
+		}
+		var evErr *EventError
+		if !errors.As(err, &evErr) || evErr.Type != tt.typ {
+			t.Errorf("%s: got %#v, want type %q", tt.name, err, tt.typ)
+		}
+	}
+
+	if _, err := EncodeEvent(Event{Type: "Dance"}); !errors.Is(err, ErrUnknownEventType) {
+		t.Errorf("encoded unknown type: %v", err)
+	}
+
+	e, err := DecodeEvent([]byte(`{"type":"","data":null,"error":"errors.wrong.action"}`))
+	if err != nil || e.Error != ErrorTypeWrongAction {
+		t.Errorf("error event: %v, %+v", err, e)
+	}
+}
+
+func TestRegisterEvent(t *testing.T) {
+	type eventDance struct {
+		Steps int `json:"steps"`
+	}
+	RegisterEvent("test.Dance", eventDance{})
+
+	b, err := EncodeEvent(Event{Type: "test.Dance", Data: eventDance{Steps: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := DecodeEvent(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, ok := e.Data.(eventDance)
+	if !ok || data.Steps != 3 {
+		t.Fatalf("got %#v", e.Data)
+	}
+
+	var v eventDance
+	if err := decodeEventData(e, &v); err != nil || v != data {
+		t.Fatalf("decodeEventData: %v, %+v", err, v)
+	}
+	if err := decodeEventData(Event{Data: json.RawMessage(`{"steps":"x"}`)}, &v); err != ErrWrongEventData {
+		t.Fatalf("decodeEventData of wrong json: %v", err)
+	}
+	if err := decodeEventData(Event{Data: EventAbatSkill{Coins: 3}}, &v); err != ErrWrongEventData {
+		t.Fatalf("decodeEventData of another type: %v", err)
+	}
+	if !bytes.Contains(b, []byte(`"steps":3`)) {
+		t.Fatalf("encoded %s", b)
+	}
+}
+
+// TestCastSkillEvent checks that data of CastSkill event gets the payload of the hero's skill
+func TestCastSkillEvent(t *testing.T) {
+	skills := []EventCastSkill{
+		{Hero: "Witch", Data: EventWitchSkill{HeroName: "Abat"}},
+		{Hero: "Blackmailer", Data: EventBlackmailerSkill{RealHero: "Abat", BluffHero: "Warlord"}},
+		{Hero: "Enchantress", Data: EventEnchantressSkill{Exchange: &EventExchangeCards{Cards: []string{"castle"}}}},
+		{Hero: "Magician", Data: EventEnchantressSkill{Swap: &EventSwapHands{TargetID: "p2"}}},
+		{Hero: "Emperor", Data: EventEmperorSkill{TargetID: "p2", Coin: true}},
+		{Hero: "Abat", Data: EventAbatSkill{Coins: 2}},
+		{Hero: "Warlord", Data: EventWarlordSkill{TargetID: "p2", Quarter: "castle"}},
+		{Hero: "Assassin", Data: EventTargetHero{HeroName: "King"}},
+		{Hero: "Thief", Data: EventTargetHero{HeroName: "King"}},
+	}
+	for _, skill := range skills {
+		e := Event{Type: EventTypeCastSkill, Data: skill}
+		b, err := EncodeEvent(e)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded, err := DecodeEvent(b)
+		if err != nil {
+			t.Fatalf("%s: %v", skill.Hero, err)
+		}
+		data, ok := decoded.Data.(EventCastSkill)
+		if !ok || reflect.TypeOf(data.Data) != reflect.TypeOf(skill.Data) || eventJSON(decoded) != eventJSON(e) {
+			t.Fatalf("%s: got %#v", skill.Hero, decoded.Data)
+		}
+	}
+
+	for _, data := range []string{
+		`{"type":"CastSkill","data":{"hero":"Nobody","data":{}}}`,
+		`{"type":"CastSkill","data":{"hero":"Witch","data":{"hero_name":"Abat","coin":true}}}`,
+		`{"type":"CastSkill","data":{"hero":"Witch","data":{"hero_name":"Abat"},"target":"p2"}}`,
+		`{"type":"CastSkill","data":{"hero":"Witch"}}`,
+	} {
+		if _, err := DecodeEvent([]byte(data)); !errors.Is(err, ErrWrongEventData) {
+			t.Errorf("%s: got %v", data, err)
+		}
+	}
+
+	// the table takes decoded event as it is
+	table, players := newTestTable(t, Witch(), Blackmailer(), Enchantress(), Abat())
+	table.currentIndex = 0
+	table.startActionPhase()
+	e, err := DecodeEvent([]byte(`{"type":"CastSkill","data":{"hero":"Witch","data":{"hero_name":"Abat"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.CastSkill(string(players[0].ID), e); err != nil {
+		t.Fatal(err)
+	}
+	if table.bewitchedTurn != Abat().Turn {
+		t.Fatal("decoded skill should bewitch the Abat")
+	}
+
+	// data decoded for another hero is rejected by the hero taking the turn
+	table, players = newTestTable(t, Witch(), Blackmailer(), Enchantress(), Abat())
+	table.currentIndex = 1
+	table.startActionPhase()
+	e, err = DecodeEvent([]byte(`{"type":"CastSkill","data":{"hero":"Assassin","data":{"hero_name":"Abat"}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.CastSkill(string(players[1].ID), e); err != ErrWrongEventData {
+		t.Fatal("skill of another hero should be rejected, got ", err)
+	}
+	if players[1].skillUsed || table.threats != nil {
+		t.Fatal("rejected skill should change nothing")
+	}
+}
@@ -0,0 +1,373 @@
+package citadels
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Types of commands which players send to the table
+const (
+	CommandTypeJoin         = "join"
+	CommandTypeLeave        = "leave"
+	CommandTypeTakeSeat     = "take_seat"
+	CommandTypeShuffleSeats = "shuffle_seats"
+	CommandTypeSetCatalog   = "set_catalog"
+	CommandTypeStart        = "start"
+	CommandTypeSelectHero   = "select_hero"
+	CommandTypeDiscardHero  = "discard_hero"
+	CommandTypeMakeAction   = "make_action"
+	CommandTypeSelectCard   = "select_card"
+	CommandTypeBuild        = "build"
+	CommandTypeEndTurn      = "end_turn"
+	CommandTypeCastSkill    = "cast_skill"
+	CommandTypeUseQuarter   = "use_quarter"
+	CommandTypePayThreat    = "pay_threat"
+	CommandTypeRevealThreat = "reveal_threat"
+	CommandTypeSalvage      = "salvage"
+
+	// CommandTypeTimeout is sent by the table itself when player is inactive for too long
+	CommandTypeTimeout = "timeout"
+)
+
+// Command is a move of player at the table as it is written to the game log
+type Command struct {
+	Type     string   `json:"type"`
+	PlayerID PlayerID `json:"player_id,omitempty"`
+
+	// Hero is name of the selected or discarded hero
+	Hero string `json:"hero,omitempty"`
+
+	// Action is ActionTypeCoin or ActionTypeCards
+	Action string `json:"action,omitempty"`
+
+	// Quarter is name of the selected, built or used quarter
+	Quarter string `json:"quarter,omitempty"`
+
+	// Seat is number of the taken seat
+	Seat int `json:"seat,omitempty"`
+
+	// Answer is whether player pays or reveals the threat or takes destroyed quarter
+	Answer bool `json:"answer,omitempty"`
+
+	// Data is data of the skill or of the quarter ability
+	Data json.RawMessage `json:"data,omitempty"`
+
+	// Catalog is catalog of the deck for CommandTypeSetCatalog
+	Catalog *Catalog `json:"catalog,omitempty"`
+}
+
+// TableCommand is a move of player during the game, see Table.Apply
+type TableCommand interface {
+	// record returns the command as it is written to the game log
+	record(pID PlayerID) Command
+
+	// apply makes the move, table is locked
+	apply(t *Table, pID PlayerID) error
+}
+
+// Commands of the game
+type (
+	// SelectHeroCommand takes the hero from the stack during PickPhase
+	SelectHeroCommand struct {
+		Hero string
+	}
+
+	// DiscardHeroCommand puts the hero from the stack aside after the pick in the game of 2 players
+	DiscardHeroCommand struct {
+		Hero string
+	}
+
+	// MakeActionCommand takes the resources of the turn, Action is ActionTypeCoin or ActionTypeCards
+	MakeActionCommand struct {
+		Action string
+	}
+
+	// SelectCardCommand keeps the card from the drawn ones
+	SelectCardCommand struct {
+		Card string
+	}
+
+	// BuildCommand builds the quarter from the hand
+	BuildCommand struct {
+		Quarter string
+	}
+
+	EndTurnCommand struct{}
+
+	// CastSkillCommand casts skill of the hero, Data is data of the skill such as EventWitchSkill
+	CastSkillCommand struct {
+		Data interface{}
+	}
+
+	// UseQuarterCommand activates ability of the completed quarter
+	UseQuarterCommand struct {
+		Quarter string
+		Data    interface{}
+	}
+
+	// PayThreatCommand is answer of threatened player
+	PayThreatCommand struct {
+		Pay bool
+	}
+
+	// RevealThreatCommand is answer of the Blackmailer to the refusal
+	RevealThreatCommand struct {
+		Reveal bool
+	}
+
+	// SalvageCommand is answer of the Graveyard owner to destruction of a quarter
+	SalvageCommand struct {
+		Take bool
+	}
+)
+
+func (c SelectHeroCommand) record(pID PlayerID) Command {
+	return Command{Type: CommandTypeSelectHero, PlayerID: pID, Hero: c.Hero}
+}
+
+func (c SelectHeroCommand) apply(t *Table, pID PlayerID) error {
+	p, ok := t.players[pID]
+	if !ok {
+		return ErrPlayerNotExists
+	}
+	return t.selectHero(p, c.Hero)
+}
+
+func (c DiscardHeroCommand) record(pID PlayerID) Command {
+	return Command{Type: CommandTypeDiscardHero, PlayerID: pID, Hero: c.Hero}
+}
+
+func (c DiscardHeroCommand) apply(t *Table, pID PlayerID) error {
+	return t.discardHero(pID, c.Hero)
+}
+
+func (c MakeActionCommand) record(pID PlayerID) Command {
+	return Command{Type: CommandTypeMakeAction, PlayerID: pID, Action: c.Action}
+}
+
+func (c MakeActionCommand) apply(t *Table, pID PlayerID) error {
+	return t.makeAction(c.Action, pID)
+}
+
+func (c SelectCardCommand) record(pID PlayerID) Command {
+	return Command{Type: CommandTypeSelectCard, PlayerID: pID, Quarter: c.Card}
+}
+
+func (c SelectCardCommand) apply(t *Table, pID PlayerID) error {
+	return t.selectCard(c.Card, pID)
+}
+
+func (c BuildCommand) record(pID PlayerID) Command {
+	return Command{Type: CommandTypeBuild, PlayerID: pID, Quarter: c.Quarter}
+}
+
+func (c BuildCommand) apply(t *Table, pID PlayerID) error {
+	return t.build(c.Quarter, pID)
+}
+
+func (c EndTurnCommand) record(pID PlayerID) Command {
+	return Command{Type: CommandTypeEndTurn, PlayerID: pID}
+}
+
+func (c EndTurnCommand) apply(t *Table, pID PlayerID) error {
+	return t.finishTurn(pID)
+}
+
+func (c CastSkillCommand) record(pID PlayerID) Command {
+	return Command{Type: CommandTypeCastSkill, PlayerID: pID, Data: commandData(Event{Data: c.Data})}
+}
+
+func (c CastSkillCommand) apply(t *Table, pID PlayerID) error {
+	return t.castSkill(pID, Event{Data: c.Data})
+}
+
+func (c UseQuarterCommand) record(pID PlayerID) Command {
+	return Command{Type: CommandTypeUseQuarter, PlayerID: pID, Quarter: c.Quarter, Data: commandData(Event{Data: c.Data})}
+}
+
+func (c UseQuarterCommand) apply(t *Table, pID PlayerID) error {
+	return t.useQuarter(pID, c.Quarter, Event{Data: c.Data})
+}
+
+func (c PayThreatCommand) record(pID PlayerID) Command {
+	return Command{Type: CommandTypePayThreat, PlayerID: pID, Answer: c.Pay}
+}
+
+func (c PayThreatCommand) apply(t *Table, pID PlayerID) error {
+	return t.payThreat(pID, c.Pay)
+}
+
+func (c RevealThreatCommand) record(pID PlayerID) Command {
+	return Command{Type: CommandTypeRevealThreat, PlayerID: pID, Answer: c.Reveal}
+}
+
+func (c RevealThreatCommand) apply(t *Table, pID PlayerID) error {
+	return t.revealThreat(pID, c.Reveal)
+}
+
+func (c SalvageCommand) record(pID PlayerID) Command {
+	return Command{Type: CommandTypeSalvage, PlayerID: pID, Answer: c.Take}
+}
+
+func (c SalvageCommand) apply(t *Table, pID PlayerID) error {
+	return t.answerSalvage(pID, c.Take)
+}
+
+// Apply makes the move of player, rejected move returns *CommandError
+func (t *Table) Apply(pID PlayerID, cmd TableCommand) error {
+	t.Lock()
+	defer t.Unlock()
+	if cmd == nil {
+		return newCommandError(ErrUnknownCommand)
+	}
+	err := t.record(cmd.record(pID), func() error {
+		return cmd.apply(t, pID)
+	})
+	return newCommandError(err)
+}
+
+// CommandError is the reason why the table rejected the command
+type CommandError struct {
+	// Code is one of ErrorType* values, the same that comes in Event.Error
+	Code string
+
+	Err error
+}
+
+func (e *CommandError) Error() string {
+	return e.Code + ": " + e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// errorTypes are codes of errors which reject commands,
+// the first error matching the rejection gives the code
+var errorTypes = []struct {
+	err  error
+	code string
+}{
+	{ErrPlayerNotExists, ErrorTypePlayerNotExists},
+	{ErrWrongPhase, ErrorTypeWrongPhase},
+	{ErrNotYourTurn, ErrorTypeNotYourTurn},
+	{ErrAnotherPlayerSelecting, ErrorTypeAnotherPlayerSelecting},
+	{ErrHeroNotInStack, ErrorTypeHeroNotInStack},
+	{ErrNoDiscard, ErrorTypeNoDiscard},
+	{ErrDiscardPending, ErrorTypeDiscardPending},
+	{ErrActionAlreadyMade, ErrorTypeActionAlreadyMade},
+	{ErrWrongAction, ErrorTypeWrongAction},
+	{ErrDeckIsEmpty, ErrorTypeDeckIsEmpty},
+	{ErrNoCardsChoice, ErrorTypeNoCardsChoice},
+	{ErrCardNotInChoice, ErrorTypeCardNotInChoice},
+	{ErrCardNotInHand, ErrorTypeCardNotInHand},
+	{ErrNoBuildChances, ErrorTypeNoBuildChances},
+	{ErrSkillNotCast, ErrorTypeSkillNotCast},
+	{ErrNotEnoughCoins, ErrorTypeNotEnoughCoins},
+	{ErrQuarterAlreadyBuilt, ErrorTypeQuarterAlreadyBuilt},
+	{ErrCityComplete, ErrorTypeCityComplete},
+	{ErrThreatUnresolved, ErrorTypeThreatUnresolved},
+	{ErrNoThreat, ErrorTypeNoThreat},
+	{ErrNoSalvage, ErrorTypeNoSalvage},
+	{ErrSalvageUnresolved, ErrorTypeSalvageUnresolved},
+	{ErrPlayerBewitched, ErrorTypePlayerBewitched},
+	{ErrSkillAlreadyUsed, ErrorTypeSkillAlreadyUsed},
+	{ErrNoActiveSkill, ErrorTypeNoActiveSkill},
+	{ErrWrongEventData, ErrorTypeWrongEventData},
+	{ErrCannotCastOnMyself, ErrorTypeCannotCastOnMyself},
+	{ErrAlreadyKing, ErrorTypeAlreadyKing},
+	{ErrPlayerProtected, ErrorTypePlayerProtected},
+	{ErrHeroNotExists, ErrorTypeHeroNotExists},
+	{ErrHeroAlreadyPlayed, ErrorTypeHeroAlreadyPlayed},
+	{ErrHeroKilled, ErrorTypeHeroKilled},
+	{ErrQuarterNotExists, ErrorTypeQuarterNotExists},
+	{ErrQuarterIndestructible, ErrorTypeQuarterIndestructible},
+	{ErrUnknownCommand, ErrorTypeUnknownCommand},
+}
+
+// ErrorTypeOf returns code of the error, ErrorTypeRejected if the error has no code
+func ErrorTypeOf(err error) string {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Code
+	}
+	for _, et := range errorTypes {
+		if errors.Is(err, et.err) {
+			return et.code
+		}
+	}
+	return ErrorTypeRejected
+}
+
+// newCommandError gives the error its code, nil stays nil
+func newCommandError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &CommandError{Code: ErrorTypeOf(err), Err: err}
+}
+
+// commandData returns data of the skill as raw json so the command can be logged
+func commandData(ev Event) json.RawMessage {
+	if ev.Data == nil {
+		return nil
+	}
+	if raw, ok := ev.Data.(json.RawMessage); ok {
+		return raw
+	}
+	data, err := json.Marshal(ev.Data)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// applyRecorded sends the command from the game log to the table through its public methods
+func (t *Table) applyRecorded(cmd Command) error {
+	switch cmd.Type {
+	case CommandTypeJoin:
+		return t.AddPlayer(NewPlayer(cmd.PlayerID, func(Event, *Player) {}))
+	case CommandTypeLeave:
+		return t.RemovePlayer(cmd.PlayerID)
+	case CommandTypeTakeSeat:
+		return t.TakeSeat(cmd.PlayerID, cmd.Seat)
+	case CommandTypeShuffleSeats:
+		return t.ShuffleSeats()
+	case CommandTypeSetCatalog:
+		if cmd.Catalog == nil {
+			return ErrWrongEventData
+		}
+		return t.SetCatalog(*cmd.Catalog)
+	case CommandTypeStart:
+		return t.Start()
+	case CommandTypeSelectHero:
+		return t.Apply(cmd.PlayerID, SelectHeroCommand{Hero: cmd.Hero})
+	case CommandTypeDiscardHero:
+		return t.Apply(cmd.PlayerID, DiscardHeroCommand{Hero: cmd.Hero})
+	case CommandTypeMakeAction:
+		return t.Apply(cmd.PlayerID, MakeActionCommand{Action: cmd.Action})
+	case CommandTypeSelectCard:
+		return t.Apply(cmd.PlayerID, SelectCardCommand{Card: cmd.Quarter})
+	case CommandTypeBuild:
+		return t.Apply(cmd.PlayerID, BuildCommand{Quarter: cmd.Quarter})
+	case CommandTypeEndTurn:
+		return t.Apply(cmd.PlayerID, EndTurnCommand{})
+	case CommandTypeCastSkill:
+		return t.Apply(cmd.PlayerID, CastSkillCommand{Data: cmd.Data})
+	case CommandTypeUseQuarter:
+		return t.Apply(cmd.PlayerID, UseQuarterCommand{Quarter: cmd.Quarter, Data: cmd.Data})
+	case CommandTypePayThreat:
+		return t.Apply(cmd.PlayerID, PayThreatCommand{Pay: cmd.Answer})
+	case CommandTypeRevealThreat:
+		return t.Apply(cmd.PlayerID, RevealThreatCommand{Reveal: cmd.Answer})
+	case CommandTypeSalvage:
+		return t.Apply(cmd.PlayerID, SalvageCommand{Take: cmd.Answer})
+	case CommandTypeTimeout:
+		t.Lock()
+		t.timeout(cmd.PlayerID, t.currentPhase)
+		t.Unlock()
+	default:
+		return ErrUnknownCommand
+	}
+	return nil
+}
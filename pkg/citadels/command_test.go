@@ -0,0 +1,107 @@
+package citadels
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+// TestApply checks that rejected commands come back with their codes
+func TestApply(t *testing.T) {
+	table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+	alchemist, architect := players[0], players[1]
+	table.currentIndex = 5
+	table.startActionPhase()
+	alchemist.Coins = 0
+	alchemist.AvailableQuarters = []Quarter{
+		{Name: "castle", Type: QuarterTypeNoble, Price: 4},
+		{Name: "tavern", Type: QuarterTypeTrade, Price: 1},
+	}
+
+	tests := []struct {
+		pID  PlayerID
+		cmd  TableCommand
+		err  error
+		code string
+	}{
+		{"nobody", EndTurnCommand{}, ErrPlayerNotExists, ErrorTypePlayerNotExists},
+		{architect.ID, MakeActionCommand{Action: ActionTypeCoin}, ErrNotYourTurn, ErrorTypeNotYourTurn},
+		{alchemist.ID, SelectHeroCommand{Hero: "Witch"}, ErrWrongPhase, ErrorTypeWrongPhase},
+		{alchemist.ID, SelectCardCommand{Card: "tavern"}, ErrNoCardsChoice, ErrorTypeNoCardsChoice},
+		{alchemist.ID, MakeActionCommand{Action: "gold"}, ErrWrongAction, ErrorTypeWrongAction},
+		{alchemist.ID, BuildCommand{Quarter: "tavern"}, ErrNotEnoughCoins, ErrorTypeNotEnoughCoins},
+		{alchemist.ID, MakeActionCommand{Action: ActionTypeCoin}, nil, ""},
+		{alchemist.ID, MakeActionCommand{Action: ActionTypeCoin}, ErrActionAlreadyMade, ErrorTypeActionAlreadyMade},
+		{alchemist.ID, BuildCommand{Quarter: "palace"}, ErrCardNotInHand, ErrorTypeCardNotInHand},
+		{alchemist.ID, BuildCommand{Quarter: "tavern"}, nil, ""},
+		{alchemist.ID, BuildCommand{Quarter: "castle"}, ErrNoBuildChances, ErrorTypeNoBuildChances},
+		{alchemist.ID, CastSkillCommand{}, ErrNoActiveSkill, ErrorTypeNoActiveSkill},
+		{alchemist.ID, PayThreatCommand{Pay: true}, ErrNoThreat, ErrorTypeNoThreat},
+		{alchemist.ID, nil, ErrUnknownCommand, ErrorTypeUnknownCommand},
+		{alchemist.ID, EndTurnCommand{}, nil, ""},
+		{alchemist.ID, EndTurnCommand{}, ErrNotYourTurn, ErrorTypeNotYourTurn},
+	}
+	for i, tt := range tests {
+		err := table.Apply(tt.pID, tt.cmd)
+		if tt.err == nil {
+			if err != nil {
+				t.Fatalf("%d %T: %v", i, tt.cmd, err)
+			}
+			continue
+		}
+
+		var cmdErr *CommandError
+		if !errors.As(err, &cmdErr) || !errors.Is(err, tt.err) || cmdErr.Code != tt.code {
+			t.Fatalf("%d %T: got %v, want %v with code %s", i, tt.cmd, err, tt.err, tt.code)
+		}
+	}
+
+	if table.Turn() != architect {
+		t.Fatal("turn should go to the architect")
+	}
+}
+
+func TestErrorTypeOf(t *testing.T) {
+	codes := make(map[string]error)
+	for _, et := range errorTypes {
+		if other, ok := codes[et.code]; ok {
+			t.Errorf("%v and %v share code %s", et.err, other, et.code)
+		}
+		codes[et.code] = et.err
+		if ErrorTypeOf(et.err) != et.code {
+			t.Errorf("%v: got %s, want %s", et.err, ErrorTypeOf(et.err), et.code)
+		}
+	}
+
+	// the error listed first wins whatever the order of the match
+	both := sentinels{ErrNotEnoughCoins, ErrPlayerNotExists}
+	for i := 0; i < 10; i++ {
+		if ErrorTypeOf(both) != ErrorTypePlayerNotExists {
+			t.Fatal("error matching two codes should get the first listed one, got ", ErrorTypeOf(both))
+		}
+	}
+
+	wrapped := fmt.Errorf("warlord: %w", ErrPlayerProtected)
+	if ErrorTypeOf(wrapped) != ErrorTypePlayerProtected {
+		t.Error("wrapped error should keep its code")
+	}
+	if ErrorTypeOf(errors.New("boom")) != ErrorTypeRejected {
+		t.Error("unknown error should be rejected without a code of its own")
+	}
+}
+
+// sentinels is an error which is every one of the errors
+type sentinels []error
+
+func (s sentinels) Error() string {
+	return "sentinels"
+}
+
+func (s sentinels) Is(target error) bool {
+	for _, err := range s {
+		if err == target {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,350 @@
+package citadels
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Hero sets
+const (
+	HeroSetDefault = "default"
+	HeroSetClassic = "classic"
+)
+
+// ContentVersion is version of definition files which the engine understands
+const ContentVersion = 1
+
+// Kinds of definition files
+const (
+	ContentKindDeck   = "deck"
+	ContentKindHeroes = "heroes"
+)
+
+var quarterTypes = []string{
+	QuarterTypeNoble, QuarterTypeMilitary, QuarterTypeTrade, QuarterTypeSpiritual, QuarterTypeSpecial,
+}
+
+var (
+	contentMu sync.RWMutex
+	heroSets  map[string][]Hero
+	catalogs  map[string]Catalog
+	skills    map[string]Skill
+)
+
+func init() {
+	// registries are filled here because skills refer to the table logic which refers to hero sets
+	skills = map[string]Skill{
+		"witch":           Witch().Skill,
+		"blackmailer":     Blackmailer().Skill,
+		"enchantress":     Enchantress().Skill,
+		"emperor":         Emperor().Skill,
+		"abat":            Abat().Skill,
+		"alchemist":       Alchemist().Skill,
+		"architect":       Architect().Skill,
+		"warlord":         Warlord().Skill,
+		"customs_officer": CustomsOfficer().Skill,
+		"assassin":        Assassin().Skill,
+		"thief":           Thief().Skill,
+		"magician":        Magician().Skill,
+		"king":            King().Skill,
+		"bishop":          Bishop().Skill,
+		"merchant":        Merchant().Skill,
+		"queen":           Queen().Skill,
+	}
+
+	heroSets = map[string][]Hero{
+		HeroSetDefault: {Witch(), Blackmailer(), Enchantress(), Emperor(), Abat(), Alchemist(), Architect(), Warlord(), CustomsOfficer()},
+		HeroSetClassic: {Assassin(), Thief(), Magician(), King(), Bishop(), Merchant(), Architect(), Warlord(), Queen()},
+	}
+
+	for _, heroes := range heroSets {
+		registerSkillPayloads(heroes)
+	}
+
+	classic := ClassicCatalog()
+	catalogs = map[string]Catalog{
+		classic.ID: classic,
+	}
+}
+
+// RegisterSkill makes skill available for heroes from definition files
+func RegisterSkill(id string, skill Skill) {
+	contentMu.Lock()
+	defer contentMu.Unlock()
+	skills[id] = skill
+}
+
+// HeroSetByID returns registered hero set
+func HeroSetByID(id string) ([]Hero, bool) {
+	contentMu.RLock()
+	defer contentMu.RUnlock()
+	heroes, ok := heroSets[id]
+	if !ok {
+		return nil, false
+	}
+	return append([]Hero(nil), heroes...), true
+}
+
+// CatalogByID returns registered catalog
+func CatalogByID(id string) (Catalog, bool) {
+	contentMu.RLock()
+	defer contentMu.RUnlock()
+	c, ok := catalogs[id]
+	return c, ok
+}
+
+// ContentError points at the invalid entry of a definition file
+type ContentError struct {
+	File string
+
+	// Entry is the invalid entry inside the file, empty if the whole file is wrong
+	Entry string
+
+	Err error
+}
+
+func (e *ContentError) Error() string {
+	if e.Entry == "" {
+		return fmt.Sprintf("%s: %v", e.File, e.Err)
+	}
+	return fmt.Sprintf("%s: %s: %v", e.File, e.Entry, e.Err)
+}
+
+func (e *ContentError) Unwrap() error {
+	return e.Err
+}
+
+// contentFile is a definition file of a deck or a hero set
+type contentFile struct {
+	Version  int          `json:"version" yaml:"version"`
+	Kind     string       `json:"kind" yaml:"kind"`
+	ID       string       `json:"id" yaml:"id"`
+	Quarters []quarterDef `json:"quarters" yaml:"quarters"`
+	Heroes   []heroDef    `json:"heroes" yaml:"heroes"`
+}
+
+type quarterDef struct {
+	Name  string `json:"name" yaml:"name"`
+	Type  string `json:"type" yaml:"type"`
+	Price int    `json:"price" yaml:"price"`
+	Count int    `json:"count" yaml:"count"`
+}
+
+type heroDef struct {
+	Name       string `json:"name" yaml:"name"`
+	Turn       int    `json:"turn" yaml:"turn"`
+	Skill      string `json:"skill" yaml:"skill"`
+	Protected  bool   `json:"protected" yaml:"protected"`
+	BuildLimit int    `json:"build_limit" yaml:"build_limit"`
+	BonusCards int    `json:"bonus_cards" yaml:"bonus_cards"`
+}
+
+// Content is decks and hero sets loaded from definition files
+type Content struct {
+	Catalogs map[string]Catalog
+	HeroSets map[string][]Hero
+}
+
+// LoadContent reads and validates every .json, .yaml and .yml file of dir
+func LoadContent(dir string) (*Content, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml":
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+
+	content := &Content{
+		Catalogs: make(map[string]Catalog),
+		HeroSets: make(map[string][]Hero),
+	}
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		err = content.loadFile(path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return content, nil
+}
+
+func (c *Content) loadFile(path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var f contentFile
+	if strings.ToLower(filepath.Ext(path)) == ".json" {
+		dec := json.NewDecoder(bytes.NewReader(b))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(&f)
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(b))
+		dec.KnownFields(true)
+		err = dec.Decode(&f)
+	}
+	if err != nil {
+		return &ContentError{File: path, Err: err}
+	}
+
+	if f.Version != ContentVersion {
+		return &ContentError{File: path, Entry: "version", Err: ErrUnsupportedVersion}
+	}
+	if f.ID == "" {
+		return &ContentError{File: path, Entry: "id", Err: ErrEmptyContentID}
+	}
+
+	switch f.Kind {
+	case ContentKindDeck:
+		if _, ok := c.Catalogs[f.ID]; ok {
+			return &ContentError{File: path, Entry: "id", Err: ErrDuplicateContentID}
+		}
+		catalog, err := f.catalog(path)
+		if err != nil {
+			return err
+		}
+		c.Catalogs[f.ID] = catalog
+	case ContentKindHeroes:
+		if _, ok := c.HeroSets[f.ID]; ok {
+			return &ContentError{File: path, Entry: "id", Err: ErrDuplicateContentID}
+		}
+		heroes, err := f.heroes(path)
+		if err != nil {
+			return err
+		}
+		c.HeroSets[f.ID] = heroes
+	default:
+		return &ContentError{File: path, Entry: "kind", Err: ErrUnknownContentKind}
+	}
+	return nil
+}
+
+func (f contentFile) catalog(path string) (Catalog, error) {
+	if len(f.Quarters) == 0 || len(f.Heroes) > 0 {
+		return Catalog{}, &ContentError{File: path, Entry: "quarters", Err: ErrWrongContentEntries}
+	}
+
+	catalog := Catalog{ID: f.ID, Entries: make([]CatalogEntry, 0, len(f.Quarters))}
+	seen := make(map[string]bool)
+	for i, q := range f.Quarters {
+		entry := fmt.Sprintf("quarters[%d] %q", i, q.Name)
+		switch {
+		case q.Name == "" || seen[q.Name]:
+			return Catalog{}, &ContentError{File: path, Entry: entry, Err: ErrDuplicateName}
+		case !isQuarterType(q.Type):
+			return Catalog{}, &ContentError{File: path, Entry: entry, Err: ErrUnknownQuarterType}
+		case q.Price < 0:
+			return Catalog{}, &ContentError{File: path, Entry: entry, Err: ErrWrongPrice}
+		case q.Count < 1:
+			return Catalog{}, &ContentError{File: path, Entry: entry, Err: ErrWrongCount}
+		}
+		seen[q.Name] = true
+
+		catalog.Entries = append(catalog.Entries, CatalogEntry{
+			Quarter: Quarter{Name: q.Name, Type: q.Type, Price: q.Price},
+			Count:   q.Count,
+		})
+	}
+	return catalog, nil
+}
+
+func (f contentFile) heroes(path string) ([]Hero, error) {
+	if len(f.Heroes) == 0 || len(f.Quarters) > 0 {
+		return nil, &ContentError{File: path, Entry: "heroes", Err: ErrWrongContentEntries}
+	}
+
+	contentMu.RLock()
+	defer contentMu.RUnlock()
+
+	heroes := make([]Hero, 0, len(f.Heroes))
+	names := make(map[string]bool)
+	turns := make(map[int]bool)
+	for i, h := range f.Heroes {
+		entry := fmt.Sprintf("heroes[%d] %q", i, h.Name)
+		skill, registered := skills[h.Skill]
+		switch {
+		case h.Name == "" || names[h.Name]:
+			return nil, &ContentError{File: path, Entry: entry, Err: ErrDuplicateName}
+		case h.Turn < 1 || h.Turn > 9:
+			return nil, &ContentError{File: path, Entry: entry, Err: ErrWrongTurn}
+		case turns[h.Turn]:
+			return nil, &ContentError{File: path, Entry: entry, Err: ErrDuplicateTurn}
+		case !registered:
+			return nil, &ContentError{File: path, Entry: entry, Err: ErrSkillNotRegistered}
+		case h.BuildLimit < 0 || h.BonusCards < 0:
+			return nil, &ContentError{File: path, Entry: entry, Err: ErrWrongCount}
+		}
+		names[h.Name] = true
+		turns[h.Turn] = true
+
+		heroes = append(heroes, Hero{
+			Name:       h.Name,
+			Turn:       h.Turn,
+			Protected:  h.Protected,
+			BuildLimit: h.BuildLimit,
+			BonusCards: h.BonusCards,
+			Skill:      skill,
+		})
+	}
+
+	// heroes always go in order of their turns
+	sort.Slice(heroes, func(i, j int) bool { return heroes[i].Turn < heroes[j].Turn })
+	return heroes, nil
+}
+
+// Register makes loaded decks and hero sets available for tables by their IDs,
+// nothing is registered if any ID is already taken or a hero name is taken by a skill with other data
+func (c *Content) Register() error {
+	contentMu.Lock()
+	defer contentMu.Unlock()
+
+	for id := range c.Catalogs {
+		if _, ok := catalogs[id]; ok {
+			return fmt.Errorf("deck %q: %w", id, ErrDuplicateContentID)
+		}
+	}
+	for id := range c.HeroSets {
+		if _, ok := heroSets[id]; ok {
+			return fmt.Errorf("hero set %q: %w", id, ErrDuplicateContentID)
+		}
+	}
+	if name, ok := skillPayloadConflict(c.HeroSets); ok {
+		return fmt.Errorf("hero %q: %w", name, ErrSkillPayloadConflict)
+	}
+
+	for id, catalog := range c.Catalogs {
+		catalogs[id] = catalog
+	}
+	for id, heroes := range c.HeroSets {
+		heroSets[id] = heroes
+		registerSkillPayloads(heroes)
+	}
+	return nil
+}
+
+func isQuarterType(quarterType string) bool {
+	for _, t := range quarterTypes {
+		if t == quarterType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,149 @@
+package citadels
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// isolateContent gives the test its own copy of registered decks and hero sets
+func isolateContent(t *testing.T) {
+	contentMu.Lock()
+	defer contentMu.Unlock()
+	savedSets, savedCatalogs := heroSets, catalogs
+
+	heroSets = make(map[string][]Hero, len(savedSets))
+	for id, heroes := range savedSets {
+		heroSets[id] = heroes
+	}
+	catalogs = make(map[string]Catalog, len(savedCatalogs))
+	for id, c := range savedCatalogs {
+		catalogs[id] = c
+	}
+
+	// loaded heroes register data of their skills too
+	eventsMu.Lock()
+	savedPayloads := skillPayloads
+	skillPayloads = make(map[string]reflect.Type, len(savedPayloads))
+	for hero, payload := range savedPayloads {
+		skillPayloads[hero] = payload
+	}
+	eventsMu.Unlock()
+
+	t.Cleanup(func() {
+		contentMu.Lock()
+		defer contentMu.Unlock()
+		heroSets, catalogs = savedSets, savedCatalogs
+		eventsMu.Lock()
+		skillPayloads = savedPayloads
+		eventsMu.Unlock()
+	})
+}
+
+// TestLoadContent loads definition files and plays with them
+func TestLoadContent(t *testing.T) {
+	isolateContent(t)
+	content, err := LoadContent(filepath.Join("testdata", "content"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(content.Catalogs["market"].Deck()) != 13 {
+		t.Fatal("deck should have every copy")
+	}
+	heroes := content.HeroSets["builders"]
+	if len(heroes) != 4 || heroes[0].Name != "Robber" || heroes[3].BuildLimit != 0 {
+		t.Fatal("heroes should be sorted by turn")
+	}
+	if heroes[2].BuildLimit != 3 || heroes[2].Skill.Type != SkillTypePassive {
+		t.Fatal("hero should get data of the file and the registered skill")
+	}
+
+	err = content.Register()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !errors.Is(content.Register(), ErrDuplicateContentID) {
+		t.Fatal("content should be registered once")
+	}
+
+	table, err := NewTable(TableOptions{HeroSet: "builders", Catalog: "market"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(table.heroes) != 4 {
+		t.Fatal("table should pick hero set by id")
+	}
+	if table.catalog.ID != "market" {
+		t.Fatal("table should pick deck by id")
+	}
+	if _, err := NewTable(TableOptions{HeroSet: "builders", Catalog: "unknown"}); err != ErrCatalogNotExists {
+		t.Fatal("deck should be registered, got ", err)
+	}
+
+	catalog, ok := CatalogByID("market")
+	if !ok || newTable(t, HeroSetDefault).SetCatalog(catalog) != nil {
+		t.Fatal("deck should also be set before the start")
+	}
+}
+
+// TestLoadContentErrors checks that errors point at the bad entry
+func TestLoadContentErrors(t *testing.T) {
+	cases := []struct {
+		file  string
+		data  string
+		err   error
+		entry string
+	}{
+		{"deck.yaml", "version: 2\nkind: deck\nid: a\n", ErrUnsupportedVersion, "version"},
+		{"deck.yaml", "version: 1\nkind: city\nid: a\n", ErrUnknownContentKind, "kind"},
+		{"deck.yaml", "version: 1\nkind: deck\nquarters: [{name: A, type: Trade, price: 1, count: 1}]\n", ErrEmptyContentID, "id"},
+		{"deck.yaml", "version: 1\nkind: deck\nid: a\nquarters:\n  - {name: A, type: Trade, price: 1, count: 1}\n  - {name: A, type: Noble, price: 1, count: 1}\n", ErrDuplicateName, `quarters[1] "A"`},
+		{"deck.yaml", "version: 1\nkind: deck\nid: a\nquarters:\n  - {name: A, type: Pink, price: 1, count: 1}\n", ErrUnknownQuarterType, `quarters[0] "A"`},
+		{"heroes.json", `{"version": 1, "kind": "heroes", "id": "a", "heroes": [{"name": "A", "turn": 10, "skill": "king"}]}`, ErrWrongTurn, `heroes[0] "A"`},
+		{"heroes.json", `{"version": 1, "kind": "heroes", "id": "a", "heroes": [{"name": "A", "turn": 1, "skill": "dance"}]}`, ErrSkillNotRegistered, `heroes[0] "A"`},
+		{"heroes.json", `{"version": 1, "kind": "heroes", "id": "a", "heroes": [{"name": "A", "turn": 1, "skill": "king"}, {"name": "A", "turn": 2, "skill": "king"}]}`, ErrDuplicateName, `heroes[1] "A"`},
+	}
+
+	for _, c := range cases {
+		dir := t.TempDir()
+		err := os.WriteFile(filepath.Join(dir, c.file), []byte(c.data), 0o644)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = LoadContent(dir)
+		var contentErr *ContentError
+		if !errors.As(err, &contentErr) || !errors.Is(err, c.err) {
+			t.Fatalf("expected %v, got %v", c.err, err)
+		}
+		if contentErr.Entry != c.entry || !strings.HasSuffix(contentErr.File, c.file) {
+			t.Fatalf("error should point at %s %s, got %v", c.file, c.entry, err)
+		}
+	}
+}
+
+// TestRegisterSkillPayloadConflict checks that a hero set can not change data of a hero name used by others
+func TestRegisterSkillPayloadConflict(t *testing.T) {
+	isolateContent(t)
+
+	warlord := Warlord()
+	warlord.Skill = Witch().Skill
+	c := &Content{HeroSets: map[string][]Hero{"rebels": {warlord}}}
+	if err := c.Register(); !errors.Is(err, ErrSkillPayloadConflict) {
+		t.Fatal("hero name should keep data of its skill, got ", err)
+	}
+	if _, ok := HeroSetByID("rebels"); ok {
+		t.Fatal("conflicting hero set should not be registered")
+	}
+	if _, err := DecodeEvent([]byte(`{"type":"CastSkill","data":{"hero":"Warlord","data":{"target_id":"p2","quarter":"castle"}}}`)); err != nil {
+		t.Fatal("data of the Warlord should stay the same, got ", err)
+	}
+
+	c = &Content{HeroSets: map[string][]Hero{"loyal": {Warlord()}}}
+	if err := c.Register(); err != nil {
+		t.Fatal("hero with the same data should be registered, got ", err)
+	}
+}
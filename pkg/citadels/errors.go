@@ -10,6 +10,70 @@ var (
 	ErrWrongEventData = errors.New("wrong event data")
 	ErrPlayerNotExists = errors.New("player does not exists")
 	ErrCannotCastOnMyself = errors.New("can not cast on myself")
+	ErrNotYourTurn = errors.New("not your turn")
+	ErrSkillAlreadyUsed = errors.New("skill already used this turn")
+	ErrPlayerBewitched = errors.New("player is bewitched")
+	ErrHeroNotExists = errors.New("hero does not exists")
+	ErrHeroAlreadyPlayed = errors.New("hero already played")
+	ErrHeroKilled = errors.New("hero is killed")
+	ErrNoThreat = errors.New("no threat to answer")
+	ErrThreatUnresolved = errors.New("threat is not resolved")
+	ErrNoSalvage = errors.New("no quarter to salvage")
+	ErrSalvageUnresolved = errors.New("salvage is not answered")
+	ErrCardNotInHand = errors.New("card is not in hand")
+	ErrAlreadyKing = errors.New("player is already a king")
+	ErrNoActiveSkill = errors.New("hero has no active skill")
+	ErrNotEnoughCoins = errors.New("not enough coins")
+	ErrQuarterNotExists = errors.New("quarter does not exists")
+	ErrCityComplete = errors.New("city is complete")
+	ErrPlayerProtected = errors.New("player is protected")
+	ErrHeroSetNotExists = errors.New("hero set does not exists")
+	ErrCatalogNotExists = errors.New("catalog does not exists")
+	ErrQuarterIndestructible = errors.New("quarter can not be destroyed")
+	ErrWrongRules = errors.New("wrong rules")
+	ErrSeatNotExists = errors.New("seat does not exists")
+	ErrUnknownCommand = errors.New("unknown command")
+	ErrWrongPhase = errors.New("wrong phase")
+	ErrAnotherPlayerSelecting = errors.New("another player is selecting")
+	ErrHeroNotInStack = errors.New("hero is not in the stack")
+	ErrActionAlreadyMade = errors.New("action already made this turn")
+	ErrWrongAction = errors.New("wrong action")
+	ErrDeckIsEmpty = errors.New("deck is empty")
+	ErrNoCardsChoice = errors.New("no cards to choose from")
+	ErrCardNotInChoice = errors.New("card is not in the choice")
+	ErrNoDiscard = errors.New("no hero to discard")
+	ErrDiscardPending = errors.New("hero has to be discarded first")
+	ErrNoBuildChances = errors.New("no build chances left")
+	ErrSkillNotCast = errors.New("skill has to be cast first")
+	ErrQuarterAlreadyBuilt = errors.New("quarter already built")
+)
+
+// Errors of game logs
+var (
+	ErrNoTableRecord = errors.New("log does not start with the table")
+	ErrReplayMismatch = errors.New("replayed game differs from the log")
+)
+
+// Errors of the event codec
+var (
+	ErrUnknownEventType = errors.New("unknown event type")
+)
+
+// Errors of definition files
+var (
+	ErrUnsupportedVersion = errors.New("unsupported version")
+	ErrUnknownContentKind = errors.New("unknown kind")
+	ErrEmptyContentID = errors.New("empty id")
+	ErrDuplicateContentID = errors.New("duplicate id")
+	ErrWrongContentEntries = errors.New("wrong entries for the kind")
+	ErrDuplicateName = errors.New("empty or duplicate name")
+	ErrUnknownQuarterType = errors.New("unknown quarter type")
+	ErrWrongPrice = errors.New("price must not be negative")
+	ErrWrongCount = errors.New("count is out of range")
+	ErrWrongTurn = errors.New("turn must be in 1..9")
+	ErrDuplicateTurn = errors.New("duplicate turn")
+	ErrSkillNotRegistered = errors.New("skill is not registered")
+	ErrSkillPayloadConflict = errors.New("hero name is taken by a skill with other data")
 )
 
 // Errors for events
@@ -22,4 +86,38 @@ var (
 	ErrorTypeWrongAction = "errors.wrong.action"
 	ErrorTypeNotEnoughCoins = "errors.not.enough.coins"
 	ErrorTypeQuarterAlreadyBuilt = "errors.quarter.built"
+	ErrorTypeSkillNotCast = "errors.skill.not.cast"
+	ErrorTypeDeckIsEmpty = "errors.deck.empty"
+
+	ErrorTypeWrongPhase = "errors.phase.wrong"
+	ErrorTypeNotYourTurn = "errors.turn.another"
+	ErrorTypePlayerNotExists = "errors.player.not.exists"
+	ErrorTypeActionAlreadyMade = "errors.action.made"
+	ErrorTypeNoCardsChoice = "errors.cards.no.choice"
+	ErrorTypeCardNotInChoice = "errors.card.not.choice"
+	ErrorTypeCardNotInHand = "errors.card.not.hand"
+	ErrorTypeNoBuildChances = "errors.build.no.chances"
+	ErrorTypeCityComplete = "errors.city.complete"
+	ErrorTypeThreatUnresolved = "errors.threat.unresolved"
+	ErrorTypeNoThreat = "errors.threat.none"
+	ErrorTypeNoSalvage = "errors.salvage.none"
+	ErrorTypeNoDiscard = "errors.discard.none"
+	ErrorTypeDiscardPending = "errors.discard.pending"
+	ErrorTypeSalvageUnresolved = "errors.salvage.unresolved"
+	ErrorTypePlayerBewitched = "errors.player.bewitched"
+	ErrorTypeSkillAlreadyUsed = "errors.skill.used"
+	ErrorTypeNoActiveSkill = "errors.skill.none"
+	ErrorTypeWrongEventData = "errors.data.wrong"
+	ErrorTypeCannotCastOnMyself = "errors.target.myself"
+	ErrorTypeAlreadyKing = "errors.target.king"
+	ErrorTypePlayerProtected = "errors.target.protected"
+	ErrorTypeHeroNotExists = "errors.hero.not.exists"
+	ErrorTypeHeroKilled = "errors.hero.killed"
+	ErrorTypeHeroAlreadyPlayed = "errors.hero.played"
+	ErrorTypeQuarterNotExists = "errors.quarter.not.exists"
+	ErrorTypeQuarterIndestructible = "errors.quarter.indestructible"
+	ErrorTypeUnknownCommand = "errors.command.unknown"
+
+	// ErrorTypeRejected is code of errors which have no code of their own
+	ErrorTypeRejected = "errors.rejected"
 )
\ No newline at end of file
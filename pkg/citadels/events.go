@@ -17,6 +17,9 @@ var (
 
 	EventTypeHeroSelected = "hero.selected"
 	EventTypeChooseHero = "ChooseHero"
+	EventTypeChooseDiscard = "ChooseDiscard"
+	EventTypeHeroDiscarded = "HeroDiscarded"
+	EventTypePlayerDiscardedHero = "PlayerDiscardedHero"
 
 	EventTypeNextTurn = "NextTurn"
 	EventTypeHeroIsAbsent = "HeroAbsent"
@@ -34,6 +37,48 @@ var (
 	EventTypePlayerBuiltQuarter = "PlayerBuiltQuarter"
 
 	EventTypeGameEnded = "GameEnded"
+
+	EventTypeHeroBewitched = "HeroBewitched"
+	EventTypePlayerBewitched = "PlayerBewitched"
+	EventTypeWitchTakesTurn = "WitchTakesTurn"
+
+	EventTypeThreatsPlaced = "ThreatsPlaced"
+	EventTypeThreatsPlacedPrivate = "ThreatsPlacedPrivate"
+	EventTypePlayerThreatened = "PlayerThreatened"
+	EventTypeChooseThreatPayment = "ChooseThreatPayment"
+	EventTypeThreatPaid = "ThreatPaid"
+	EventTypeThreatRefused = "ThreatRefused"
+	EventTypeChooseThreatReveal = "ChooseThreatReveal"
+	EventTypeThreatRevealed = "ThreatRevealed"
+
+	EventTypeHandsSwapped = "HandsSwapped"
+	EventTypeHandsSwappedPrivate = "HandsSwappedPrivate"
+	EventTypeCardsExchanged = "CardsExchanged"
+	EventTypeCardsExchangedPrivate = "CardsExchangedPrivate"
+
+	EventTypeCrownMoved = "CrownMoved"
+
+	EventTypeIncomeCollected = "IncomeCollected"
+	EventTypeTithePaid = "TithePaid"
+
+	EventTypeCoinsRefunded = "CoinsRefunded"
+
+	EventTypePlayerDrewCards = "PlayerDrewCards"
+
+	EventTypeQuarterDestroyed = "QuarterDestroyed"
+	EventTypeCoinsPaid = "CoinsPaid"
+
+	EventTypeTaxCollected = "TaxCollected"
+	EventTypeTaxReturned = "TaxReturned"
+
+	EventTypeHeroKilled = "HeroKilled"
+	EventTypeKilledHeroSkipped = "KilledHeroSkipped"
+	EventTypeHeroRobbed = "HeroRobbed"
+
+	EventTypeQuarterUsed = "QuarterUsed"
+	EventTypeQuarterSalvaged = "QuarterSalvaged"
+	EventTypeChooseSalvage = "ChooseSalvage"
+	EventTypeSalvageDeclined = "SalvageDeclined"
 )
 
 type Event struct {
@@ -55,6 +100,65 @@ type(
 		Coin bool `json:"coin"`
 	}
 
+	// EventEnchantressSkill must have exactly one of the options
+	EventEnchantressSkill struct {
+		Swap *EventSwapHands `json:"swap,omitempty"`
+		Exchange *EventExchangeCards `json:"exchange,omitempty"`
+	}
+
+	EventSwapHands struct {
+		// TargetID is player whose hand is taken
+		TargetID PlayerID `json:"target_id"`
+	}
+
+	EventExchangeCards struct {
+		// Cards are names of cards which go to the bottom of the deck
+		Cards []string `json:"cards"`
+	}
+
+	EventAbatSkill struct {
+		// Coins is how many resources of the income are taken in coins, the rest is taken in cards
+		Coins int `json:"coins"`
+	}
+
+	EventWarlordSkill struct {
+		// TargetID is player whose quarter is destroyed
+		TargetID PlayerID `json:"target_id"`
+
+		// Quarter is name of the completed quarter
+		Quarter string `json:"quarter"`
+	}
+
+	// EventCastSkill is request of a player to cast the skill of his hero,
+	// Data is decoded into the payload registered for the hero such as EventWitchSkill
+	EventCastSkill struct {
+		Hero string `json:"hero"`
+		Data interface{} `json:"data"`
+	}
+
+	// EventTargetHero is data of skills that name a hero
+	EventTargetHero struct {
+		HeroName string `json:"hero_name"`
+	}
+
+	// EventCardName is data of abilities that take a card from the hand
+	EventCardName struct {
+		Card string `json:"card"`
+	}
+
+	EventWitchSkill struct {
+		// HeroName is name of the hero that Witch bewitches
+		HeroName string `json:"hero_name"`
+	}
+
+	EventBlackmailerSkill struct {
+		// RealHero is name of the hero which gets real threat marker
+		RealHero string `json:"real_hero"`
+
+		// BluffHero is name of the hero which gets bluff marker
+		BluffHero string `json:"bluff_hero"`
+	}
+
 	EventSteal struct {
 		// FromID is who gives coin/card away
 		FromID PlayerID `json:"from_id"`
@@ -76,7 +180,10 @@ type(
 	}
 
 	EventGameStarted struct {
-		King *Player `json:"king"`
+		King PlayerID `json:"king"`
+		Rules RuleSet `json:"rules"`
+		// Seats are ids of players clockwise
+		Seats []PlayerID `json:"seats"`
 	}
 
 	EventPickPhaseStarted struct {
@@ -98,6 +205,7 @@ type(
 
 	EventNextTurn struct {
 		PlayerID PlayerID `json:"player_id"`
+		// Hero is the hero taking the turn, player with two heroes gets a turn for each of them
 		Hero Hero `json:"hero"`
 		Turn int `json:"turn"`
 	}
@@ -141,5 +249,128 @@ type(
 
 	EventGameEnded struct {
 		Winner PlayerID `json:"winner"`
+		// Scores are points of every player, the winner goes first
+		Scores []Score `json:"scores"`
+	}
+
+	EventHeroBewitched struct {
+		HeroName string `json:"hero_name"`
+		Turn int `json:"turn"`
+	}
+
+	EventPlayerBewitched struct {
+		PlayerID PlayerID `json:"player_id"`
+		WitchID PlayerID `json:"witch_id"`
+	}
+
+	EventWitchTakesTurn struct {
+		WitchID PlayerID `json:"witch_id"`
+
+		// PlayerID is bewitched player
+		PlayerID PlayerID `json:"player_id"`
+
+		// Hero is bewitched hero whose skill the Witch uses
+		Hero Hero `json:"hero"`
+	}
+
+	EventThreats struct {
+		BlackmailerID PlayerID `json:"blackmailer_id"`
+
+		// Heroes are names of threatened heroes
+		Heroes []string `json:"heroes"`
+
+		// RealHero is sent only to the Blackmailer
+		RealHero string `json:"real_hero,omitempty"`
+	}
+
+	EventThreatPayment struct {
+		BlackmailerID PlayerID `json:"blackmailer_id"`
+
+		// Price is how many coins player pays to remove the marker
+		Price int `json:"price"`
+	}
+
+	EventThreatRevealed struct {
+		// PlayerID is threatened player
+		PlayerID PlayerID `json:"player_id"`
+		BlackmailerID PlayerID `json:"blackmailer_id"`
+		HeroName string `json:"hero_name"`
+		Real bool `json:"real"`
+
+		// Count is how many coins the Blackmailer took
+		Count int `json:"count"`
+	}
+
+	EventHandsSwapped struct {
+		CasterID PlayerID `json:"caster_id"`
+		TargetID PlayerID `json:"target_id"`
+
+		// CasterCards and TargetCards are sizes of new hands
+		CasterCards int `json:"caster_cards"`
+		TargetCards int `json:"target_cards"`
+	}
+
+	EventHandsSwappedCards struct {
+		CasterID PlayerID `json:"caster_id"`
+		TargetID PlayerID `json:"target_id"`
+
+		// New info about Player.AvailableQuarters
+		AvailableQuarters []Quarter `json:"available_quarters"`
+	}
+
+	EventCardsExchanged struct {
+		PlayerID PlayerID `json:"player_id"`
+		Count int `json:"count"`
+	}
+
+	EventCrownMoved struct {
+		From PlayerID `json:"from"`
+		To PlayerID `json:"to"`
+	}
+
+	EventIncome struct {
+		PlayerID PlayerID `json:"player_id"`
+
+		// QuarterType is type of quarters that brought the income
+		QuarterType string `json:"quarter_type"`
+		Coins int `json:"coins"`
+		Cards int `json:"cards"`
+	}
+
+	EventPlayerDrewCards struct {
+		PlayerID PlayerID `json:"player_id"`
+		Count int `json:"count"`
+	}
+
+	EventQuarterDestroyed struct {
+		PlayerID PlayerID `json:"player_id"`
+		TargetID PlayerID `json:"target_id"`
+		Quarter Quarter `json:"quarter"`
+	}
+
+	EventCoinsPaid struct {
+		PlayerID PlayerID `json:"player_id"`
+		Amount int `json:"amount"`
+		Sum int `json:"sum"`
+	}
+
+	EventTax struct {
+		// PlayerID is who pays the tax
+		PlayerID PlayerID `json:"player_id,omitempty"`
+
+		// CollectorID is empty while the tax waits on the Customs Officer card
+		CollectorID PlayerID `json:"collector_id,omitempty"`
+		Amount int `json:"amount"`
+	}
+
+	EventHeroTargeted struct {
+		// PlayerID is player who cast the skill
+		PlayerID PlayerID `json:"player_id"`
+		HeroName string `json:"hero_name"`
+	}
+
+	EventQuarterUsed struct {
+		PlayerID PlayerID `json:"player_id"`
+		Quarter string `json:"quarter"`
 	}
 )
\ No newline at end of file
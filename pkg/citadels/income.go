@@ -0,0 +1,79 @@
+package citadels
+
+// collectIncome gives Player one resource for every completed quarter of quarterType,
+// coins of them are paid in coins and the rest in cards
+func (t *Table) collectIncome(p *Player, quarterType string, coins int) error {
+	income := p.quartersOfType(quarterType)
+	if coins < 0 || coins > income {
+		return ErrWrongEventData
+	}
+
+	cards := t.drawFromDeck(income - coins)
+	p.AddCoins(coins)
+	for _, card := range cards {
+		p.AddQuarter(card)
+	}
+
+	if len(cards) > 0 {
+		p.Notify(Event{
+			Type: EventTypeDrawCards,
+			Data: EventCards{Cards: cards},
+		})
+	}
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeIncomeCollected,
+		Data: EventIncome{
+			PlayerID:    p.ID,
+			QuarterType: quarterType,
+			Coins:       coins,
+			Cards:       len(cards),
+		},
+	})
+	return nil
+}
+
+// takeIncome gives Player a coin for every completed quarter of quarterType
+func (t *Table) takeIncome(p *Player, quarterType string) {
+	income := p.quartersOfType(quarterType)
+	if income > 0 {
+		t.collectIncome(p, quarterType, income)
+	}
+}
+
+// richestPlayer returns the player other than p with the most coins,
+// nobody is the richest if p has at least as many coins,
+// a tie between other players is won by the first of them clockwise from p
+func (t *Table) richestPlayer(p *Player) (*Player, bool) {
+	var richest *Player
+	for next := t.playerAfter(p); next.ID != p.ID; next = t.playerAfter(next) {
+		if richest == nil || next.Coins > richest.Coins {
+			richest = next
+		}
+	}
+
+	if richest == nil || richest.Coins == 0 || richest.Coins <= p.Coins {
+		return nil, false
+	}
+	return richest, true
+}
+
+// collectTithe makes the richest player give one coin to p
+func (t *Table) collectTithe(p *Player) {
+	richest, ok := t.richestPlayer(p)
+	if !ok {
+		return
+	}
+
+	richest.AddCoins(-1)
+	p.AddCoins(1)
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeTithePaid,
+		Data: EventSteal{
+			FromID: richest.ID,
+			To:     p.ID,
+			Count:  1,
+		},
+	})
+}
@@ -0,0 +1,145 @@
+package citadels
+
+// LegalActions returns every command which the table accepts from the player right now,
+// commands of skills and quarters come with every data they accept,
+// except the exchange of cards which names the whole hand and accepts any part of it
+func (t *Table) LegalActions(pID PlayerID) []TableCommand {
+	t.Lock()
+	defer t.Unlock()
+
+	p, ok := t.players[pID]
+	if !ok {
+		return nil
+	}
+
+	actions := make([]TableCommand, 0)
+	switch t.currentPhase {
+	case PickPhase:
+		if t.selecting == nil || t.selecting.ID != p.ID {
+			return actions
+		}
+		for _, hero := range t.heroesToSelect {
+			if t.discarding {
+				actions = append(actions, DiscardHeroCommand{Hero: hero.Name})
+				continue
+			}
+			actions = append(actions, SelectHeroCommand{Hero: hero.Name})
+		}
+	case ActionPhase:
+		actions = append(actions, t.turnActions(p)...)
+	}
+	return actions
+}
+
+// turnActions returns commands of p during ActionPhase
+func (t *Table) turnActions(p *Player) []TableCommand {
+	actions := make([]TableCommand, 0)
+
+	// the Blackmailer answers the refusal during the turn of another player
+	if _, err := t.checkRevealThreat(p.ID); err == nil {
+		actions = append(actions, RevealThreatCommand{Reveal: true}, RevealThreatCommand{Reveal: false})
+	}
+
+	// the Graveyard owner answers during the turn of the Warlord
+	if d, err := t.checkSalvage(p.ID); err == nil {
+		if d.SalvagedBy.Coins >= 1 {
+			actions = append(actions, SalvageCommand{Take: true})
+		}
+		actions = append(actions, SalvageCommand{Take: false})
+	}
+
+	if _, err := t.checkPayThreat(p); err == nil {
+		actions = append(actions, PayThreatCommand{Pay: true}, PayThreatCommand{Pay: false})
+	}
+
+	for _, action := range []string{ActionTypeCoin, ActionTypeCards} {
+		if t.checkAction(p, action) == nil {
+			actions = append(actions, MakeActionCommand{Action: action})
+		}
+	}
+
+	for _, name := range distinctNames(p.currentCardsChoice) {
+		if t.checkSelectCard(p, name) == nil {
+			actions = append(actions, SelectCardCommand{Card: name})
+		}
+	}
+
+	for _, name := range distinctNames(p.AvailableQuarters) {
+		if _, err := t.checkBuild(p, name); err == nil {
+			actions = append(actions, BuildCommand{Quarter: name})
+		}
+	}
+
+	if t.checkCast(p) == nil {
+		for _, data := range t.targets(t.turnHero.Skill.Targets, p) {
+			actions = append(actions, CastSkillCommand{Data: data})
+		}
+	}
+
+	for _, name := range distinctNames(p.CompletedQuarters) {
+		ability, err := t.checkUseQuarter(p, name)
+		if err != nil {
+			continue
+		}
+		for _, data := range t.targets(ability.Targets, p) {
+			actions = append(actions, UseQuarterCommand{Quarter: name, Data: data})
+		}
+	}
+
+	if t.checkEndTurn(p) == nil {
+		actions = append(actions, EndTurnCommand{})
+	}
+	return actions
+}
+
+// targets returns data for the cast, cast without TargetsFunc takes no data
+func (t *Table) targets(targets TargetsFunc, caster *Player) []interface{} {
+	if targets == nil {
+		return []interface{}{nil}
+	}
+	return targets(t, caster)
+}
+
+// heroesAfter returns heroes of the set which take their turns after the turn
+func (t *Table) heroesAfter(turn int) []Hero {
+	heroes := make([]Hero, 0)
+	for _, hero := range t.heroes {
+		if hero.Turn > turn {
+			heroes = append(heroes, hero)
+		}
+	}
+	return heroes
+}
+
+// othersOf returns players clockwise without p
+func (t *Table) othersOf(p *Player) []*Player {
+	others := make([]*Player, 0, len(t.seats))
+	for _, other := range t.seats {
+		if other.ID != p.ID {
+			others = append(others, other)
+		}
+	}
+	return others
+}
+
+// distinctNames returns names of quarters without repeats
+func distinctNames(quarters []Quarter) []string {
+	names := make([]string, 0, len(quarters))
+	seen := make(map[string]bool)
+	for _, q := range quarters {
+		if !seen[q.Name] {
+			seen[q.Name] = true
+			names = append(names, q.Name)
+		}
+	}
+	return names
+}
+
+// quarterNames returns names of quarters in their order
+func quarterNames(quarters []Quarter) []string {
+	names := make([]string, 0, len(quarters))
+	for _, q := range quarters {
+		names = append(names, q.Name)
+	}
+	return names
+}
@@ -0,0 +1,343 @@
+package citadels
+
+import (
+	"encoding/json"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// legalCatalog is the classic deck where quarters with abilities are cheap and common
+func legalCatalog() Catalog {
+	c := ClassicCatalog()
+	c.ID = "legal"
+	for i, entry := range c.Entries {
+		if entry.Quarter.Type == QuarterTypeSpecial {
+			c.Entries[i].Quarter.Price = 1
+			c.Entries[i].Count = 3
+		}
+	}
+	return c
+}
+
+// TestLegalActions plays random legal moves and checks in every state
+// that listed commands are accepted and the rest of the candidates are rejected,
+// an exchange of a part of the listed cards is accepted too
+func TestLegalActions(t *testing.T) {
+	playLegal(t, HeroSetDefault, 1)
+	playLegal(t, HeroSetClassic, 2)
+}
+
+func playLegal(t *testing.T, heroSet string, seed int64) {
+	table, err := NewTable(TableOptions{HeroSet: heroSet, Seed: seed})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []PlayerID{"p1", "p2", "p3", "p4"} {
+		table.AddPlayer(newPlayer(id, func(Event, *Player) {}, 1000))
+	}
+	if err := table.SetCatalog(legalCatalog()); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	rng := rand.New(rand.NewSource(seed))
+	for step := 0; step < 150; step++ {
+		s := table.Snapshot()
+		if s.Phase == EndGamePhase {
+			return
+		}
+
+		moves := make([]PlayerID, 0)
+		legal := make(map[PlayerID][]TableCommand)
+		for _, pID := range table.Seats() {
+			legal[pID] = table.LegalActions(pID)
+			checkLegal(t, s, pID, legal[pID], rng)
+			for range legal[pID] {
+				moves = append(moves, pID)
+			}
+		}
+		if len(moves) == 0 {
+			t.Fatalf("%s %d step %d: nobody can move", heroSet, seed, step)
+		}
+
+		pID := moves[rng.Intn(len(moves))]
+		cmd := legal[pID][rng.Intn(len(legal[pID]))]
+		if err := table.Apply(pID, cmd); err != nil {
+			t.Fatalf("%s %d step %d: listed %s rejected: %v", heroSet, seed, step, commandKey(pID, cmd), err)
+		}
+	}
+}
+
+// checkLegal applies every listed command and a sample of other candidates to own copy of the state
+func checkLegal(t *testing.T, s Snapshot, pID PlayerID, legal []TableCommand, rng *rand.Rand) {
+	listed := make(map[string]bool)
+	for _, cmd := range legal {
+		listed[commandKey(pID, cmd)] = true
+	}
+
+	// waiting players are mostly rejected for the same reason, a few tries are enough
+	limit := 10
+	if len(legal) > 0 {
+		limit = 40
+	}
+	others := make([]TableCommand, 0)
+	for _, cmd := range candidates(t, s, pID) {
+		if !listed[commandKey(pID, cmd)] {
+			others = append(others, cmd)
+		}
+	}
+	if len(others) > limit {
+		rng.Shuffle(len(others), func(i, j int) { others[i], others[j] = others[j], others[i] })
+		others = others[:limit]
+	}
+
+	for _, cmd := range append(append([]TableCommand(nil), legal...), others...) {
+		table := probeTable(t, s)
+		key := commandKey(pID, cmd)
+		err := table.Apply(pID, cmd)
+		if listed[key] && err != nil {
+			t.Fatalf("%s: listed command rejected: %v", key, err)
+		}
+		if !listed[key] && err == nil && !partOfExchange(legal, cmd) {
+			t.Fatalf("%s: command is accepted but not listed in %s", key, s.Phase)
+		}
+	}
+}
+
+// probeTable restores the state without timers and with small queues of events,
+// so copies are freed right after the command
+func probeTable(t *testing.T, s Snapshot) *Table {
+	table, err := restoreTable(s, func(id PlayerID) *Player {
+		return newPlayer(id, func(Event, *Player) {}, 32)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	table.noTimers = true
+	return table
+}
+
+// commandKey identifies the command as it is logged
+func commandKey(pID PlayerID, cmd TableCommand) string {
+	b, _ := json.Marshal(cmd.record(pID))
+	return string(b)
+}
+
+// candidates returns commands that a player could try in the state
+func candidates(t *testing.T, s Snapshot, pID PlayerID) []TableCommand {
+	table := probeTable(t, s)
+	p := table.players[pID]
+
+	cmds := []TableCommand{
+		SelectHeroCommand{Hero: "Nobody"},
+		MakeActionCommand{Action: ActionTypeCoin},
+		MakeActionCommand{Action: ActionTypeCards},
+		MakeActionCommand{Action: "gold"},
+		SelectCardCommand{Card: "Nothing"},
+		BuildCommand{Quarter: "Nothing"},
+		EndTurnCommand{},
+		PayThreatCommand{Pay: true},
+		PayThreatCommand{Pay: false},
+		RevealThreatCommand{Reveal: true},
+		RevealThreatCommand{Reveal: false},
+		SalvageCommand{Take: true},
+		SalvageCommand{Take: false},
+		CastSkillCommand{},
+		UseQuarterCommand{Quarter: "Nothing"},
+	}
+	for _, hero := range table.heroes {
+		cmds = append(cmds, SelectHeroCommand{Hero: hero.Name}, DiscardHeroCommand{Hero: hero.Name})
+	}
+
+	names := make([]string, 0)
+	for _, q := range append(append(append([]Quarter(nil), p.AvailableQuarters...), p.CompletedQuarters...), p.currentCardsChoice...) {
+		names = append(names, q.Name)
+	}
+	for _, name := range names {
+		cmds = append(cmds, SelectCardCommand{Card: name}, BuildCommand{Quarter: name})
+	}
+	for _, q := range p.CompletedQuarters {
+		cmds = append(cmds, UseQuarterCommand{Quarter: q.Name})
+		for _, name := range append(names, "Nothing") {
+			cmds = append(cmds, UseQuarterCommand{Quarter: q.Name, Data: EventCardName{Card: name}})
+		}
+	}
+
+	if table.turn == nil || table.turn.ID != pID {
+		return cmds
+	}
+	for _, data := range skillCandidates(table, p) {
+		cmds = append(cmds, CastSkillCommand{Data: data})
+	}
+	return cmds
+}
+
+// skillCandidates returns data of the skill of current hero with targets valid and not
+func skillCandidates(table *Table, p *Player) []interface{} {
+	heroNames := []string{"Nobody"}
+	for _, hero := range table.heroes {
+		heroNames = append(heroNames, hero.Name)
+	}
+	playerIDs := append(table.seatIDs(), "nobody")
+
+	data := make([]interface{}, 0)
+	switch table.turnHero.Name {
+	case "Witch", "Assassin", "Thief":
+		for _, name := range heroNames {
+			data = append(data, EventTargetHero{HeroName: name})
+		}
+	case "Blackmailer":
+		for _, real := range heroNames {
+			for _, bluff := range heroNames {
+				data = append(data, EventBlackmailerSkill{RealHero: real, BluffHero: bluff})
+			}
+		}
+	case "Emperor":
+		for _, id := range playerIDs {
+			data = append(data, EventEmperorSkill{TargetID: id, Coin: true}, EventEmperorSkill{TargetID: id, Coin: false})
+		}
+	case "Enchantress", "Magician":
+		for _, id := range playerIDs {
+			data = append(data, EventEnchantressSkill{Swap: &EventSwapHands{TargetID: id}})
+		}
+		hand := quarterNames(p.AvailableQuarters)
+		exchanges := [][]string{{}, hand, {"Nothing"}}
+		for _, name := range hand {
+			exchanges = append(exchanges, []string{name}, append([]string{name}, hand...))
+		}
+		for _, cards := range exchanges {
+			data = append(data, EventEnchantressSkill{Exchange: &EventExchangeCards{Cards: cards}})
+		}
+		data = append(data, EventEnchantressSkill{})
+	case "Abat":
+		for coins := -1; coins <= p.quartersOfType(QuarterTypeSpiritual)+1; coins++ {
+			data = append(data, EventAbatSkill{Coins: coins})
+		}
+	case "Warlord":
+		for _, other := range table.seats {
+			for _, q := range append(other.CompletedQuarters, Quarter{Name: "Nothing"}) {
+				data = append(data, EventWarlordSkill{TargetID: other.ID, Quarter: q.Name})
+			}
+		}
+	}
+	return data
+}
+
+// exchangeOf returns cards of the exchange, ok is false for other commands
+func exchangeOf(cmd TableCommand) ([]string, bool) {
+	cast, ok := cmd.(CastSkillCommand)
+	if !ok {
+		return nil, false
+	}
+	data, ok := cast.Data.(EventEnchantressSkill)
+	if !ok || data.Exchange == nil || data.Swap != nil {
+		return nil, false
+	}
+	return data.Exchange.Cards, true
+}
+
+// partOfExchange reports whether cmd exchanges a part of the cards named by a listed exchange
+func partOfExchange(legal []TableCommand, cmd TableCommand) bool {
+	cards, ok := exchangeOf(cmd)
+	if !ok {
+		return false
+	}
+	for _, l := range legal {
+		named, ok := exchangeOf(l)
+		if !ok {
+			continue
+		}
+		left := make(map[string]int)
+		for _, name := range named {
+			left[name]++
+		}
+		part := true
+		for _, name := range cards {
+			left[name]--
+			if left[name] < 0 {
+				part = false
+			}
+		}
+		if part {
+			return true
+		}
+	}
+	return false
+}
+
+// TestLegalExchange checks that a big hand gives one exchange which accepts any part of the hand
+func TestLegalExchange(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Blackmailer(), Enchantress(), Abat())
+	enchantress := players[2]
+	for i := 0; i < 18; i++ {
+		enchantress.AvailableQuarters = append(enchantress.AvailableQuarters, Quarter{Name: "card" + strconv.Itoa(i%12), Price: 1})
+	}
+	table.noTimers = true
+	table.currentIndex = 2
+	table.startActionPhase()
+
+	start := time.Now()
+	legal := table.LegalActions(enchantress.ID)
+	if len(legal) > 20 || time.Since(start) > 100*time.Millisecond {
+		t.Fatalf("%d commands in %v, exchange should be listed once", len(legal), time.Since(start))
+	}
+
+	var exchanges int
+	for _, cmd := range legal {
+		if _, ok := exchangeOf(cmd); ok {
+			exchanges++
+		}
+	}
+	part := CastSkillCommand{Data: EventEnchantressSkill{Exchange: &EventExchangeCards{Cards: []string{"card0", "card0", "card5"}}}}
+	if exchanges != 1 || !partOfExchange(legal, part) {
+		t.Fatal("exchange should name the whole hand, got ", exchanges)
+	}
+	if err := table.Apply(enchantress.ID, part); err != nil {
+		t.Fatal(err)
+	}
+	if len(enchantress.AvailableQuarters) != 18 {
+		t.Fatal("exchanged cards should be replaced from the deck")
+	}
+}
+
+// TestLegalThreat checks answers to the Blackmailer
+func TestLegalThreat(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Blackmailer(), Enchantress(), Abat())
+	blackmailer, enchantress := players[1], players[2]
+	table.currentIndex = 1
+	table.startActionPhase()
+	if err := table.Apply(blackmailer.ID, CastSkillCommand{Data: EventBlackmailerSkill{RealHero: "Enchantress", BluffHero: "Abat"}}); err != nil {
+		t.Fatal(err)
+	}
+	table.Apply(blackmailer.ID, EndTurnCommand{})
+
+	enchantress.Coins = 4
+	table.Apply(enchantress.ID, MakeActionCommand{Action: ActionTypeCoin})
+	if !hasCommand(table.LegalActions(enchantress.ID), PayThreatCommand{Pay: false}) {
+		t.Fatal("threatened player should answer the Blackmailer")
+	}
+	if hasCommand(table.LegalActions(enchantress.ID), EndTurnCommand{}) {
+		t.Fatal("threatened player can not end the turn before the answer")
+	}
+
+	table.Apply(enchantress.ID, PayThreatCommand{Pay: false})
+	if !hasCommand(table.LegalActions(blackmailer.ID), RevealThreatCommand{Reveal: true}) {
+		t.Fatal("the Blackmailer should decide about the refusal")
+	}
+	if len(table.LegalActions(enchantress.ID)) != 0 {
+		t.Fatal("threatened player waits for the Blackmailer")
+	}
+}
+
+func hasCommand(cmds []TableCommand, cmd TableCommand) bool {
+	for _, c := range cmds {
+		if commandKey("", c) == commandKey("", cmd) {
+			return true
+		}
+	}
+	return false
+}
@@ -20,9 +20,9 @@ type Player struct {
 	// BuildChancesLeft shows how many Quarter`s player can build this round
 	BuildChancesLeft int
 
-	// Hero that the player chooses each round
+	// Heroes that the player chooses each round, one hero or two in the game of 2 or 3 players
 	// Has unique spells which can change outcome of the game
-	Hero Hero `json:"-"`
+	Heroes []Hero `json:"-"`
 
 	// In-game currency for building quarters
 	Coins int `json:"coins"`
@@ -36,8 +36,20 @@ type Player struct {
 
 	madeAction bool
 
+	// skillUsed shows whether the player has cast a skill this turn
+	skillUsed bool
+
+	// spentThisTurn is how many coins player spent on quarters this turn
+	spentThisTurn int
+
+	// usedQuarters are names of quarters activated this turn
+	usedQuarters map[string]bool
+
 	currentCardsChoice []Quarter
 
+	// cardsToKeep is how many cards player still chooses from currentCardsChoice
+	cardsToKeep int
+
 	totalScore int
 
 	updates chan Event
@@ -46,12 +58,18 @@ type Player struct {
 }
 
 func NewPlayer(id PlayerID, onEvent OnEventFunc) *Player {
+	return newPlayer(id, onEvent, 10000000)
+}
+
+// newPlayer returns player whose updates hold up to size events
+func newPlayer(id PlayerID, onEvent OnEventFunc, size int) *Player {
 	return &Player{
 		ID:      id,
-		updates: make(chan Event, 10000000),
+		updates: make(chan Event, size),
 		currentCardsChoice: make([]Quarter, 0),
 		AvailableQuarters: make([]Quarter, 0),
 		CompletedQuarters: make([]Quarter, 0),
+		usedQuarters: make(map[string]bool),
 		OnEvent: onEvent,
 	}
 }
@@ -73,6 +91,50 @@ func (p *Player) TotalScore() int  {
 	return p.totalScore
 }
 
+// resetTurnState clears everything player did during his previous turn
+func (p *Player) resetTurnState() {
+	p.madeAction = false
+	p.skillUsed = false
+	p.spentThisTurn = 0
+	p.usedQuarters = make(map[string]bool)
+	p.currentCardsChoice = nil
+}
+
+// quartersOfType returns number of completed quarters which bring income of given type
+func (p *Player) quartersOfType(quarterType string) int {
+	var count int
+	for _, quarter := range p.CompletedQuarters {
+		if quarter.Type == quarterType {
+			count++
+			continue
+		}
+		if ability, ok := abilityOf(quarter); ok && ability.AnyTypeForIncome {
+			count++
+		}
+	}
+	return count
+}
+
+// districtTypes returns number of different quarter types in the city,
+// quarters that count as any type fill the missing types
+func (p *Player) districtTypes() int {
+	types := make(map[string]bool)
+	var wildcards int
+	for _, quarter := range p.CompletedQuarters {
+		if ability, ok := abilityOf(quarter); ok && ability.AnyTypeForScore {
+			wildcards++
+			continue
+		}
+		types[quarter.Type] = true
+	}
+
+	count := len(types) + wildcards
+	if count > len(quarterTypes) {
+		count = len(quarterTypes)
+	}
+	return count
+}
+
 func (p *Player) builtQuarter(quarterName string) bool {
 	p.Lock()
 	defer p.Unlock()
@@ -84,15 +146,29 @@ func (p *Player) builtQuarter(quarterName string) bool {
 	return false
 }
 
-func (p *Player) hasQuarter(quarterName string) bool {
+// quarterInHand returns quarter with given name from Player.AvailableQuarters
+func (p *Player) quarterInHand(quarterName string) (Quarter, bool) {
 	p.Lock()
 	defer p.Unlock()
 	for _, quarter := range p.AvailableQuarters{
 		if quarter.Name == quarterName{
-			return true
+			return quarter, true
 		}
 	}
-	return false
+	return Quarter{}, false
+}
+
+// takeFromHand removes quarter with given name from Player.AvailableQuarters and returns it
+func (p *Player) takeFromHand(quarterName string) (Quarter, bool) {
+	p.Lock()
+	defer p.Unlock()
+	for _, quarter := range p.AvailableQuarters{
+		if quarter.Name == quarterName{
+			p.AvailableQuarters = removeQuarterByName(p.AvailableQuarters, quarterName)
+			return quarter, true
+		}
+	}
+	return Quarter{}, false
 }
 
 func (p *Player) buildQuarter(quarter Quarter) {
@@ -100,13 +176,12 @@ func (p *Player) buildQuarter(quarter Quarter) {
 	defer p.Unlock()
 	p.CompletedQuarters = append(p.CompletedQuarters, quarter)
 	p.AvailableQuarters = removeQuarterByName(p.AvailableQuarters, quarter.Name)
-	p.BuildChancesLeft--
 }
 
 func (p *Player) SubtractBuildChancesLeft(i int) {
 	p.Lock()
 	defer p.Unlock()
-	p.BuildChancesLeft =- i
+	p.BuildChancesLeft -= i
 }
 
 func (p *Player) Updates() <-chan Event {
@@ -116,6 +191,14 @@ func (p *Player) Updates() <-chan Event {
 func (p *Player) Notify(e Event){
 	p.Lock()
 	defer p.Unlock()
+	p.send(e)
+}
+
+// send logs the event and puts it to updates, player has to be locked
+func (p *Player) send(e Event) {
+	if p.Table != nil {
+		p.Table.recordEvent(p.ID, e)
+	}
 	p.updates <- e
 }
 
@@ -141,7 +224,7 @@ func (p *Player) giveCoins(other *Player,coins int) {
 	p.Lock()
 	defer p.Unlock()
 
-	if p.Coins <= 0 || p.Coins - coins <= 0{
+	if p.Coins < coins {
 		return
 	}
 
@@ -154,10 +237,10 @@ func (p *Player) giveCoins(other *Player,coins int) {
 		Count:  coins,
 	}}
 
-	p.updates <- ev
+	p.send(ev)
 	other.Notify(ev)
 
-	p.Table.BroadcastEvent(Event{
+	p.Table.doBroadcastEvent(Event{
 		Type: EventTypeStealCoin,
 		Data: EventSteal{To: other.ID, FromID: p.ID, Count: coins},
 	})
@@ -183,11 +266,11 @@ func (p *Player) giveRandomCards(other *Player, cards int) {
 		}
 	}
 
-	p.updates <- Event{Type: EventTypeStealCardPrivate, Data: EventStealCards{
+	p.send(Event{Type: EventTypeStealCardPrivate, Data: EventStealCards{
 		FromID: p.ID,
 		To:     other.ID,
 		AvailableQuarters:  p.AvailableQuarters,
-	}}
+	}})
 
 	other.Notify(Event{Type: EventTypeStealCardPrivate, Data: EventStealCards{
 		FromID: p.ID,
@@ -195,8 +278,18 @@ func (p *Player) giveRandomCards(other *Player, cards int) {
 		AvailableQuarters:  other.AvailableQuarters,
 	}})
 
-	p.Table.BroadcastEvent(Event{
+	p.Table.doBroadcastEvent(Event{
 		Type: EventTypeStealCard,
 		Data: EventSteal{To: other.ID, FromID: p.ID, Count: cards},
 	})
 }
+
+// heroByTurn returns hero of the player which takes given turn
+func (p *Player) heroByTurn(turn int) (Hero, bool) {
+	for _, hero := range p.Heroes {
+		if hero.Turn == turn {
+			return hero, true
+		}
+	}
+	return Hero{}, false
+}
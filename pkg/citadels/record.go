@@ -0,0 +1,228 @@
+package citadels
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// Record is a line of the game log, it holds exactly one of Table, Command or Event
+type Record struct {
+	Seq  int       `json:"seq"`
+	Time time.Time `json:"time"`
+
+	// Table is options of the table with its seed, it is always the first record
+	Table *TableOptions `json:"table,omitempty"`
+
+	Command *Command       `json:"command,omitempty"`
+	Event   *RecordedEvent `json:"event,omitempty"`
+}
+
+// RecordedEvent is Event sent by the table
+type RecordedEvent struct {
+	// To is player who got the event, empty if it was sent to everyone
+	To PlayerID `json:"to,omitempty"`
+
+	Type  string          `json:"type"`
+	Data  json.RawMessage `json:"data,omitempty"`
+	Error string          `json:"error,omitempty"`
+}
+
+// Recorder writes every command and event of the table as a line of json
+type Recorder struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+	seq int
+	err error
+
+	// onRecord receives records instead of the writer
+	onRecord func(Record)
+
+	// pending are records of the command being applied, they are written once the command is accepted
+	pending  []Record
+	applying bool
+}
+
+// NewRecorder returns recorder which appends the log to w
+func NewRecorder(w io.Writer) *Recorder {
+	return &Recorder{enc: json.NewEncoder(w)}
+}
+
+// Err returns the first error of writing the log
+func (r *Recorder) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.err
+}
+
+func (r *Recorder) write(rec Record) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.applying {
+		r.pending = append(r.pending, rec)
+		return
+	}
+	r.writeLocked(rec)
+}
+
+// writeLocked numbers the record and writes it, recorder has to be locked
+func (r *Recorder) writeLocked(rec Record) {
+	r.seq++
+	rec.Seq = r.seq
+	rec.Time = time.Now()
+
+	if r.onRecord != nil {
+		r.onRecord(rec)
+		return
+	}
+	if r.err != nil {
+		return
+	}
+	r.err = r.enc.Encode(rec)
+}
+
+// begin holds back records until the command is accepted or rejected
+func (r *Recorder) begin(cmd Command) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.applying = true
+	r.pending = []Record{{Command: &cmd}}
+}
+
+// end writes the command with its events if it was accepted,
+// rejection which only notified the player is dropped
+func (r *Recorder) end(accepted bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pending := r.pending
+	r.applying = false
+	r.pending = nil
+
+	if !accepted && !changesGame(pending) {
+		return
+	}
+	for _, rec := range pending {
+		r.writeLocked(rec)
+	}
+}
+
+// changesGame reports whether there are events other than error notifications
+func changesGame(records []Record) bool {
+	for _, rec := range records {
+		if rec.Event != nil && rec.Event.Type != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// record applies the command and logs it with the events it caused if the table has a recorder,
+// commands rejected without changing the game stay out of the log
+func (t *Table) record(cmd Command, apply func() error) error {
+	if t.recorder == nil {
+		return apply()
+	}
+	t.recorder.begin(cmd)
+	err := apply()
+	t.recorder.end(err == nil)
+	return err
+}
+
+// recordEvent logs the event sent to player with id to, or to everyone if to is empty
+func (t *Table) recordEvent(to PlayerID, e Event) {
+	if t.recorder == nil {
+		return
+	}
+	data, err := json.Marshal(e.Data)
+	if err != nil || e.Data == nil {
+		data = nil
+	}
+	t.recorder.write(Record{Event: &RecordedEvent{
+		To:    to,
+		Type:  e.Type,
+		Data:  data,
+		Error: e.Error,
+	}})
+}
+
+// Replay rebuilds the table from the log step by step
+// and checks that it sends the same events as the recorded one
+func Replay(r io.Reader) (*Table, error) {
+	records, err := readRecords(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 || records[0].Table == nil {
+		return nil, ErrNoTableRecord
+	}
+
+	replayed := make([]Record, 0, len(records))
+	recorder := &Recorder{onRecord: func(rec Record) {
+		replayed = append(replayed, rec)
+	}}
+
+	opts := *records[0].Table
+	opts.Delays = false
+	opts.Recorder = recorder
+	t, err := NewTable(opts)
+	if err != nil {
+		return nil, err
+	}
+	// timeouts are in the log, timers of the replayed table would add their own
+	t.noTimers = true
+
+	for i, rec := range records[1:] {
+		if rec.Command != nil {
+			t.applyRecorded(*rec.Command)
+		}
+
+		recorder.mu.Lock()
+		var got Record
+		if i+1 < len(replayed) {
+			got = replayed[i+1]
+		}
+		recorder.mu.Unlock()
+
+		if !sameRecord(rec, got) {
+			return t, fmt.Errorf("record %d: %w", rec.Seq, ErrReplayMismatch)
+		}
+	}
+	return t, nil
+}
+
+// readRecords reads lines of the log
+func readRecords(r io.Reader) ([]Record, error) {
+	records := make([]Record, 0)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var rec Record
+		err := json.Unmarshal(scanner.Bytes(), &rec)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	return records, scanner.Err()
+}
+
+// sameRecord compares records without their time
+func sameRecord(a, b Record) bool {
+	a.Time, b.Time = time.Time{}, time.Time{}
+	left, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	right, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	return bytes.Equal(left, right)
+}
@@ -0,0 +1,136 @@
+package citadels
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// playRecorded plays a game of bots and returns its log
+func playRecorded(t *testing.T) *bytes.Buffer {
+	log := &bytes.Buffer{}
+	table, err := NewTable(TableOptions{HeroSet: HeroSetClassic, Seed: 3, Recorder: NewRecorder(log)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []PlayerID{"p1", "p2", "p3", "p4"} {
+		table.AddPlayer(NewPlayer(id, func(Event, *Player) {}))
+	}
+	table.ShuffleSeats()
+	if err := table.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 500 && botStep(table); i++ {
+	}
+	return log
+}
+
+// botStep makes the simplest move for player who selects a hero or takes a turn,
+// returns false if the game is not going
+func botStep(table *Table) bool {
+	table.Lock()
+	phase, selecting, turn := table.currentPhase, table.selecting, table.turn
+	var hero string
+	if phase == PickPhase {
+		hero = table.heroesToSelect[0].Name
+	}
+	table.Unlock()
+
+	switch phase {
+	case PickPhase:
+		table.SelectHero(selecting, hero)
+	case ActionPhase:
+		if len(turn.AvailableQuarters) == 0 {
+			table.MakeAction(ActionTypeCards, string(turn.ID))
+			if len(turn.currentCardsChoice) > 0 {
+				table.SelectCard(turn.currentCardsChoice[0].Name, string(turn.ID))
+			}
+		} else {
+			table.MakeAction(ActionTypeCoin, string(turn.ID))
+		}
+		buildAffordable(table, turn)
+		table.EndTurn(turn.ID)
+	default:
+		return false
+	}
+	return true
+}
+
+// TestReplay checks that the log rebuilds the same game
+func TestReplay(t *testing.T) {
+	log := playRecorded(t)
+	lines := strings.Split(strings.TrimSpace(log.String()), "\n")
+	if len(lines) < 100 || !strings.Contains(lines[0], `"seed":3`) {
+		t.Fatal("log should start with the table and hold the game")
+	}
+
+	table, err := Replay(strings.NewReader(log.String()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Seed() != 3 {
+		t.Fatal("replayed table should have the recorded seed")
+	}
+
+	// any change of the game is found
+	broken := strings.Replace(log.String(), `"coins":`, `"coins":1`, 1)
+	if _, err := Replay(strings.NewReader(broken)); !errors.Is(err, ErrReplayMismatch) {
+		t.Fatal("changed log should not match, got ", err)
+	}
+
+	if _, err := Replay(strings.NewReader(strings.Join(lines[1:], "\n"))); err != ErrNoTableRecord {
+		t.Fatal("log should start with the table, got ", err)
+	}
+}
+
+// TestRecordAccepted checks that rejected commands stay out of the log
+func TestRecordAccepted(t *testing.T) {
+	log := &bytes.Buffer{}
+	table, err := NewTable(TableOptions{HeroSet: HeroSetClassic, Seed: 5, Recorder: NewRecorder(log)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := table.Start(); err != ErrNotEnoughPlayers {
+		t.Fatal("table should not start without players, got ", err)
+	}
+	for _, id := range []PlayerID{"p1", "p2", "p3", "p4"} {
+		table.AddPlayer(newPlayer(id, func(Event, *Player) {}, 1000))
+	}
+	if err := table.TakeSeat("nobody", 1); err != ErrPlayerNotExists {
+		t.Fatal("unknown player should not take a seat, got ", err)
+	}
+	if err := table.Start(); err != nil {
+		t.Fatal(err)
+	}
+	table.Lock()
+	selecting := table.selecting.ID
+	table.Unlock()
+	if err := table.Apply(selecting, SelectHeroCommand{Hero: "Nobody"}); err == nil {
+		t.Fatal("unknown hero should be rejected")
+	}
+
+	records, err := readRecords(bytes.NewReader(log.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	commands := make([]string, 0)
+	for _, rec := range records {
+		if rec.Command != nil {
+			commands = append(commands, rec.Command.Type)
+		}
+	}
+	want := []string{CommandTypeJoin, CommandTypeJoin, CommandTypeJoin, CommandTypeJoin, CommandTypeStart}
+	if strings.Join(commands, " ") != strings.Join(want, " ") {
+		t.Fatal("log should hold only accepted commands, got ", commands)
+	}
+
+	replayed, err := Replay(bytes.NewReader(log.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !replayed.noTimers {
+		t.Fatal("replayed table should not run timers")
+	}
+}
@@ -0,0 +1,138 @@
+package citadels
+
+import (
+	"fmt"
+	"time"
+)
+
+// RuleSet is numbers of the game which differ between official and house rules
+type RuleSet struct {
+	// Name of the preset, empty for custom rules
+	Name string `json:"name"`
+
+	// CitySize is number of quarters that completes a city and ends the game
+	CitySize int `json:"city_size"`
+
+	// StartingGold is how many coins every player gets at the start
+	StartingGold int `json:"starting_gold"`
+
+	// StartingHand is how many cards every player gets at the start
+	StartingHand int `json:"starting_hand"`
+
+	// CoinIncome is how many coins the coin action gives
+	CoinIncome int `json:"coin_income"`
+
+	// CardsDrawn is how many cards the cards action draws
+	CardsDrawn int `json:"cards_drawn"`
+
+	// CardsKept is how many of the drawn cards player keeps
+	CardsKept int `json:"cards_kept"`
+
+	// BuildLimit is how many quarters can be built per turn unless the hero says otherwise
+	BuildLimit int `json:"build_limit"`
+
+	// TurnTimeout ends the turn of inactive player
+	TurnTimeout time.Duration `json:"turn_timeout"`
+
+	// SelectTimeout selects random hero for inactive player
+	SelectTimeout time.Duration `json:"select_timeout"`
+}
+
+// Names of rule presets
+const (
+	RulesOfficial = "official"
+	RulesQuick    = "quick"
+	RulesLong     = "long"
+)
+
+// OfficialRules returns rules from the rulebook
+func OfficialRules() RuleSet {
+	return RuleSet{
+		Name:          RulesOfficial,
+		CitySize:      7,
+		StartingGold:  2,
+		StartingHand:  4,
+		CoinIncome:    2,
+		CardsDrawn:    2,
+		CardsKept:     1,
+		BuildLimit:    1,
+		TurnTimeout:   time.Minute,
+		SelectTimeout: time.Minute,
+	}
+}
+
+// QuickRules is a house rule for short games, cities are smaller and players are richer
+func QuickRules() RuleSet {
+	r := OfficialRules()
+	r.Name = RulesQuick
+	r.CitySize = 6
+	r.StartingGold = 4
+	r.TurnTimeout = 30 * time.Second
+	r.SelectTimeout = 30 * time.Second
+	return r
+}
+
+// LongRules is a house rule for long games with bigger cities
+func LongRules() RuleSet {
+	r := OfficialRules()
+	r.Name = RulesLong
+	r.CitySize = 8
+	return r
+}
+
+// RulesByName returns preset of rules
+func RulesByName(name string) (RuleSet, bool) {
+	switch name {
+	case RulesOfficial:
+		return OfficialRules(), true
+	case RulesQuick:
+		return QuickRules(), true
+	case RulesLong:
+		return LongRules(), true
+	}
+	return RuleSet{}, false
+}
+
+// Validate reports the first number of the rules which makes the game unplayable
+func (r RuleSet) Validate() error {
+	switch {
+	case r.CitySize < 1:
+		return fmt.Errorf("city size %d: %w", r.CitySize, ErrWrongRules)
+	case r.StartingGold < 0:
+		return fmt.Errorf("starting gold %d: %w", r.StartingGold, ErrWrongRules)
+	case r.StartingHand < 0:
+		return fmt.Errorf("starting hand %d: %w", r.StartingHand, ErrWrongRules)
+	case r.CoinIncome < 0:
+		return fmt.Errorf("coin income %d: %w", r.CoinIncome, ErrWrongRules)
+	case r.CardsDrawn < 1:
+		return fmt.Errorf("cards drawn %d: %w", r.CardsDrawn, ErrWrongRules)
+	case r.CardsKept < 1 || r.CardsKept > r.CardsDrawn:
+		return fmt.Errorf("cards kept %d of %d: %w", r.CardsKept, r.CardsDrawn, ErrWrongRules)
+	case r.BuildLimit < 1:
+		return fmt.Errorf("build limit %d: %w", r.BuildLimit, ErrWrongRules)
+	case r.TurnTimeout <= 0 || r.SelectTimeout <= 0:
+		return fmt.Errorf("timeouts %v, %v: %w", r.TurnTimeout, r.SelectTimeout, ErrWrongRules)
+	}
+	return nil
+}
+
+// TableOptions are settings of a new table
+type TableOptions struct {
+	// Delays makes the table pause between events so clients can show them
+	Delays bool `json:"delays"`
+
+	// HeroSet is id of heroes which the table plays with
+	HeroSet string `json:"hero_set"`
+
+	// Catalog is id of the deck which the table plays with, the classic deck is used if empty
+	Catalog string `json:"catalog,omitempty"`
+
+	// Seed of randomness of the table, seed is taken from the clock if zero
+	Seed int64 `json:"seed"`
+
+	// Rules of the table, official rules are used if empty
+	Rules RuleSet `json:"rules"`
+
+	// Recorder logs the game if set
+	Recorder *Recorder `json:"-"`
+}
@@ -0,0 +1,133 @@
+package citadels
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestRulePresets checks that every preset is playable
+func TestRulePresets(t *testing.T) {
+	for _, name := range []string{RulesOfficial, RulesQuick, RulesLong} {
+		rules, ok := RulesByName(name)
+		if !ok || rules.Name != name {
+			t.Fatal("preset should exist ", name)
+		}
+		if err := rules.Validate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rules := OfficialRules()
+	rules.CardsKept = 3
+	if _, err := NewTable(TableOptions{HeroSet: HeroSetDefault, Rules: rules}); !errors.Is(err, ErrWrongRules) {
+		t.Fatal("kept cards should not exceed drawn cards, got ", err)
+	}
+	if _, err := NewTable(TableOptions{HeroSet: "unknown"}); err != ErrHeroSetNotExists {
+		t.Fatal("hero set should exist, got ", err)
+	}
+}
+
+// TestCustomRules checks that the table plays by its rules
+func TestCustomRules(t *testing.T) {
+	rules := OfficialRules()
+	rules.StartingGold = 5
+	rules.StartingHand = 2
+	rules.CoinIncome = 3
+	rules.CardsDrawn = 3
+	rules.CardsKept = 2
+	rules.BuildLimit = 2
+
+	table, err := NewTable(TableOptions{HeroSet: HeroSetDefault, Rules: rules})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Rules() != rules {
+		t.Fatal("rules should be visible")
+	}
+
+	p := NewPlayer("p1", func(Event, *Player) {})
+	table.AddPlayer(p)
+	table.drawCards()
+	if p.Coins != 5 || len(p.AvailableQuarters) != 2 {
+		t.Fatal("player should start with the gold and the hand of the rules")
+	}
+
+	table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+	table.rules = rules
+	alchemist, warlord := players[0], players[2]
+
+	table.startActionPhase()
+	if alchemist.BuildChancesLeft != 2 {
+		t.Fatal("build limit should come from the rules")
+	}
+	table.MakeAction(ActionTypeCards, string(alchemist.ID))
+	if len(alchemist.currentCardsChoice) != 3 {
+		t.Fatal("player should choose from three cards")
+	}
+
+	deck := len(table.deck)
+	table.SelectCard(alchemist.currentCardsChoice[0].Name, string(alchemist.ID))
+	table.SelectCard(alchemist.currentCardsChoice[0].Name, string(alchemist.ID))
+	if len(alchemist.AvailableQuarters) != 2 || len(table.deck) != deck+1 {
+		t.Fatal("player should keep two cards")
+	}
+
+	table.EndTurn(alchemist.ID)
+	table.EndTurn(players[1].ID)
+	table.MakeAction(ActionTypeCoin, string(warlord.ID))
+	if warlord.Coins != 3 {
+		t.Fatal("coin action should give income of the rules")
+	}
+}
+
+// TestSeed checks that tables with the same seed play the same game
+func TestSeed(t *testing.T) {
+	start := func(seed int64) *Table {
+		table, err := NewTable(TableOptions{HeroSet: HeroSetDefault, Seed: seed})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, id := range []PlayerID{"p1", "p2", "p3", "p4", "p5"} {
+			table.AddPlayer(NewPlayer(id, func(Event, *Player) {}))
+		}
+		if err := table.Start(); err != nil {
+			t.Fatal(err)
+		}
+		table.Lock()
+		defer table.Unlock()
+		return table
+	}
+
+	a, b := start(42), start(42)
+	if a.Seed() != 42 {
+		t.Fatal("seed should be exposed")
+	}
+	if a.king.ID != b.king.ID {
+		t.Fatal("king should depend on the seed")
+	}
+	for id, p := range a.players {
+		if b.players[id].Order != p.Order {
+			t.Fatal("seats should depend on the seed")
+		}
+		if !reflect.DeepEqual(b.players[id].AvailableQuarters, p.AvailableQuarters) {
+			t.Fatal("hands should depend on the seed")
+		}
+	}
+	if !reflect.DeepEqual(a.deck, b.deck) || heroNames(a.heroesToSelect) != heroNames(b.heroesToSelect) {
+		t.Fatal("deck and heroes should depend on the seed")
+	}
+
+	if c := start(7); reflect.DeepEqual(a.deck, c.deck) {
+		t.Fatal("other seed should shuffle the deck otherwise")
+	}
+}
+
+// heroNames joins names of heroes
+func heroNames(heroes []Hero) string {
+	var names string
+	for _, hero := range heroes {
+		names += hero.Name + ","
+	}
+	return names
+}
@@ -0,0 +1,88 @@
+package citadels
+
+import "sort"
+
+// Points of the end game bonuses
+const (
+	// ColoursBonus is given for quarters of every type in the city
+	ColoursBonus = 3
+	// FirstCompleteBonus is given to the player who completed the city first
+	FirstCompleteBonus = 4
+	// CompleteBonus is given to other players who completed the city
+	CompleteBonus = 2
+)
+
+// Score is breakdown of points which player gets at the end of the game
+type Score struct {
+	PlayerID PlayerID `json:"player_id"`
+
+	// Quarters is sum of prices of completed quarters
+	Quarters int `json:"quarters"`
+
+	// Colours is bonus for quarters of every type
+	Colours int `json:"colours"`
+
+	// Completion is bonus for the complete city
+	Completion int `json:"completion"`
+
+	// Special is bonus from abilities of special quarters
+	Special int `json:"special"`
+
+	Total int `json:"total"`
+
+	// HeroTurn is turn of the highest ranked hero of the last round, it breaks ties
+	HeroTurn int `json:"hero_turn"`
+}
+
+// score counts points of p
+func (t *Table) score(p *Player) Score {
+	s := Score{PlayerID: p.ID}
+	for _, quarter := range p.CompletedQuarters {
+		s.Quarters += quarter.Price
+	}
+
+	if p.districtTypes() == len(quarterTypes) {
+		s.Colours = ColoursBonus
+	}
+
+	if t.completedQuartersFirst != nil && t.completedQuartersFirst.ID == p.ID {
+		s.Completion = FirstCompleteBonus
+	} else if len(p.CompletedQuarters) >= t.rules.CitySize {
+		s.Completion = CompleteBonus
+	}
+
+	quarterHooks(p, func(q Quarter, ability QuarterAbility) {
+		if ability.OnScore != nil {
+			s.Special += ability.OnScore(t, p)
+		}
+	})
+
+	for _, hero := range p.Heroes {
+		if hero.Turn > s.HeroTurn {
+			s.HeroTurn = hero.Turn
+		}
+	}
+
+	s.Total = s.Quarters + s.Colours + s.Completion + s.Special
+	return s
+}
+
+// scores returns points of every player, the winner goes first
+// tie is won by the player with the highest ranked hero of the last round
+func (t *Table) scores() []Score {
+	scores := make([]Score, 0, len(t.players))
+	for _, p := range t.players {
+		scores = append(scores, t.score(p))
+	}
+
+	sort.Slice(scores, func(i, j int) bool {
+		if scores[i].Total != scores[j].Total {
+			return scores[i].Total > scores[j].Total
+		}
+		if scores[i].HeroTurn != scores[j].HeroTurn {
+			return scores[i].HeroTurn > scores[j].HeroTurn
+		}
+		return scores[i].PlayerID < scores[j].PlayerID
+	})
+	return scores
+}
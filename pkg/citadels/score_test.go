@@ -0,0 +1,62 @@
+package citadels
+
+import "testing"
+
+// TestScores checks the breakdown of points and the tiebreaker
+func TestScores(t *testing.T) {
+	table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+	alchemist, architect, warlord, officer := players[0], players[1], players[2], players[3]
+	alchemist.CompletedQuarters = table.drawFromDeck(table.rules.CitySize)
+	architect.CompletedQuarters = table.drawFromDeck(table.rules.CitySize)
+	warlord.CompletedQuarters = []Quarter{
+		{Name: "fortress", Type: QuarterTypeMilitary, Price: 5},
+		{Name: "castle", Type: QuarterTypeNoble, Price: 5},
+		{Name: "temple", Type: QuarterTypeSpiritual, Price: 1},
+		special(QuarterHauntedQuarter, 2),
+		special(QuarterDragonGate, 6),
+	}
+	officer.CompletedQuarters = []Quarter{
+		{Name: "palace", Type: QuarterTypeNoble, Price: 5},
+		{Name: "manor", Type: QuarterTypeNoble, Price: 5},
+		{Name: "market", Type: QuarterTypeTrade, Price: 5},
+		{Name: "harbor", Type: QuarterTypeTrade, Price: 6},
+		{Name: "castle", Type: QuarterTypeNoble, Price: 2},
+		{Name: "hall", Type: QuarterTypeNoble, Price: 1},
+	}
+	table.completedQuartersFirst = architect
+
+	table.endRound()
+	scores := table.scores()
+	byID := make(map[PlayerID]Score)
+	for _, s := range scores {
+		byID[s.PlayerID] = s
+	}
+
+	if s := byID[architect.ID]; s.Completion != FirstCompleteBonus || s.Colours != ColoursBonus || s.Total != 14 {
+		t.Fatal("first complete city should get 4 points and colours bonus, got ", s)
+	}
+	if s := byID[alchemist.ID]; s.Completion != CompleteBonus || s.Total != 12 {
+		t.Fatal("other complete city should get 2 points, got ", s)
+	}
+	if s := byID[warlord.ID]; s.Quarters != 19 || s.Colours != ColoursBonus || s.Special != 2 || s.Total != 24 {
+		t.Fatal("haunted quarter should complete the colours, got ", s)
+	}
+
+	// warlord and officer have 24 points, the officer has higher hero
+	if scores[0].PlayerID != officer.ID || scores[1].PlayerID != warlord.ID {
+		t.Fatal("tie should be won by the highest ranked hero, got ", scores)
+	}
+	if officer.TotalScore() != 24 {
+		t.Fatal("total score should be saved")
+	}
+
+	var ended EventGameEnded
+	for _, e := range drainEvents(alchemist) {
+		if e.Type == EventTypeGameEnded {
+			ended = e.Data.(EventGameEnded)
+		}
+	}
+	if ended.Winner != officer.ID || len(ended.Scores) != len(players) {
+		t.Fatal("scores should be announced")
+	}
+}
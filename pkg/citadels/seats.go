@@ -0,0 +1,109 @@
+package citadels
+
+// reseat gives every player number of his seat
+func (t *Table) reseat() {
+	for i, p := range t.seats {
+		p.Order = i + 1
+	}
+}
+
+// seated returns players in order of their seats
+func (t *Table) seated() []*Player {
+	return append([]*Player(nil), t.seats...)
+}
+
+// seatIDs returns ids of players in order of their seats
+func (t *Table) seatIDs() []PlayerID {
+	ids := make([]PlayerID, len(t.seats))
+	for i, p := range t.seats {
+		ids[i] = p.ID
+	}
+	return ids
+}
+
+// playerAfter returns player who sits next to p clockwise
+func (t *Table) playerAfter(p *Player) *Player {
+	if len(t.seats) == 0 {
+		return p
+	}
+	return t.seats[p.Order%len(t.seats)]
+}
+
+// playerAt returns player at the seat, seats are numbered from 1
+func (t *Table) playerAt(seat int) (*Player, bool) {
+	if seat < 1 || seat > len(t.seats) {
+		return nil, false
+	}
+	return t.seats[seat-1], true
+}
+
+// PlayerAt returns player at the seat, seats are numbered from 1
+func (t *Table) PlayerAt(seat int) (*Player, bool) {
+	t.Lock()
+	defer t.Unlock()
+	return t.playerAt(seat)
+}
+
+// NextPlayer returns player who sits next to player with given id clockwise
+func (t *Table) NextPlayer(pID PlayerID) (*Player, bool) {
+	t.Lock()
+	defer t.Unlock()
+	p, ok := t.players[pID]
+	if !ok {
+		return nil, false
+	}
+	return t.playerAfter(p), true
+}
+
+// Seats returns ids of players clockwise
+func (t *Table) Seats() []PlayerID {
+	t.Lock()
+	defer t.Unlock()
+	return t.seatIDs()
+}
+
+// TakeSeat moves player to the seat before the start, players between shift by one seat
+func (t *Table) TakeSeat(pID PlayerID, seat int) error {
+	t.Lock()
+	defer t.Unlock()
+	return t.record(Command{Type: CommandTypeTakeSeat, PlayerID: pID, Seat: seat}, func() error {
+		return t.takeSeat(pID, seat)
+	})
+}
+
+// takeSeat moves player to the seat
+func (t *Table) takeSeat(pID PlayerID, seat int) error {
+	if t.started {
+		return ErrTableAlreadyStarted
+	}
+	p, ok := t.players[pID]
+	if !ok {
+		return ErrPlayerNotExists
+	}
+	if seat < 1 || seat > len(t.seats) {
+		return ErrSeatNotExists
+	}
+
+	seats := removePlayer(t.seats, p.Order-1)
+	seats = append(seats[:seat-1], append([]*Player{p}, seats[seat-1:]...)...)
+	t.seats = seats
+	t.reseat()
+	return nil
+}
+
+// ShuffleSeats seats players randomly before the start
+func (t *Table) ShuffleSeats() error {
+	t.Lock()
+	defer t.Unlock()
+	return t.record(Command{Type: CommandTypeShuffleSeats}, t.shuffleSeats)
+}
+
+// shuffleSeats seats players in random order
+func (t *Table) shuffleSeats() error {
+	if t.started {
+		return ErrTableAlreadyStarted
+	}
+	t.rng.Shuffle(len(t.seats), func(i, j int) { t.seats[i], t.seats[j] = t.seats[j], t.seats[i] })
+	t.reseat()
+	return nil
+}
@@ -0,0 +1,57 @@
+package citadels
+
+import (
+	"reflect"
+	"testing"
+)
+
+// TestSeats checks choosing seats and lookups around the table
+func TestSeats(t *testing.T) {
+	table := newTable(t, HeroSetDefault)
+	for _, id := range []PlayerID{"p1", "p2", "p3", "p4"} {
+		table.AddPlayer(NewPlayer(id, func(Event, *Player) {}))
+	}
+
+	if err := table.TakeSeat("p4", 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.TakeSeat("p1", 5); err != ErrSeatNotExists {
+		t.Fatal("seat should exist, got ", err)
+	}
+	if !reflect.DeepEqual(table.Seats(), []PlayerID{"p4", "p1", "p2", "p3"}) {
+		t.Fatal("player should move to the chosen seat, got ", table.Seats())
+	}
+
+	if p, ok := table.PlayerAt(2); !ok || p.ID != "p1" || p.Order != 2 {
+		t.Fatal("p1 should sit at the second seat")
+	}
+	if p, _ := table.NextPlayer("p3"); p.ID != "p4" {
+		t.Fatal("last seat should be followed by the first one")
+	}
+
+	table.RemovePlayer("p1")
+	if p, _ := table.NextPlayer("p4"); p.ID != "p2" {
+		t.Fatal("players should close the gap of the left player")
+	}
+	table.AddPlayer(NewPlayer("p5", func(Event, *Player) {}))
+
+	if err := table.ShuffleSeats(); err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range table.Seats() {
+		if p, _ := table.PlayerAt(i + 1); p.ID != id || p.Order != i+1 {
+			t.Fatal("seats should match order of players")
+		}
+	}
+
+	seats := table.Seats()
+	if err := table.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(table.Seats(), seats) {
+		t.Fatal("start should keep the seats")
+	}
+	if err := table.TakeSeat("p2", 1); err != ErrTableAlreadyStarted {
+		t.Fatal("seats should not change during the game, got ", err)
+	}
+}
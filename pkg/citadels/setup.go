@@ -0,0 +1,115 @@
+package citadels
+
+// removal is how many heroes are put aside before the pick phase
+type removal struct {
+	// Open heroes are shown to everyone
+	Open int
+
+	// Closed heroes are hidden from everyone
+	Closed int
+
+	// LastTakesClosed lets the last selecting player choose the closed heroes too
+	LastTakesClosed bool
+
+	// PickerDiscards makes every selecting player but the first put one more hero aside face down,
+	// the hero left alone after the last pick is put aside without a choice
+	PickerDiscards bool
+}
+
+// removals are official rules for every number of players
+var removals = map[int]removal{
+	2: {Open: 0, Closed: 1, PickerDiscards: true},
+	3: {Open: 0, Closed: 1},
+	4: {Open: 2, Closed: 1},
+	5: {Open: 1, Closed: 1},
+	6: {Open: 0, Closed: 1},
+	7: {Open: 0, Closed: 1, LastTakesClosed: true},
+	8: {Open: 0, Closed: 1},
+}
+
+// heroesPerPlayer returns how many heroes every player controls,
+// in the game of 2 or 3 players everyone has two heroes
+func heroesPerPlayer(players int) int {
+	if players <= 3 {
+		return 2
+	}
+	return 1
+}
+
+// requiredHeroes returns size of hero set needed by given number of players,
+// 8 players need the ninth hero
+func requiredHeroes(players int) int {
+	r := removals[players]
+	picks := players * heroesPerPlayer(players)
+	if r.PickerDiscards {
+		return picks*2 - 1 + r.Open + r.Closed
+	}
+	return picks + r.Open + r.Closed
+}
+
+// maxPlayers returns how many players can sit at the table with its hero set
+func (t *Table) maxPlayers() int {
+	max := 0
+	for n := MinPlayers; n <= MaxPlayers; n++ {
+		if requiredHeroes(n) <= len(t.heroes) {
+			max = n
+		}
+	}
+	return max
+}
+
+// removeHeroes puts heroes aside according to the number of players,
+// the hero with the crown is never shown and is replaced by the next one
+func (t *Table) removeHeroes(heroSet []Hero) []Hero {
+	r := removals[len(t.players)]
+
+	t.closedLockedHeroes = append(make([]Hero, 0), heroSet[:r.Closed]...)
+	t.openLockedHeroes = make([]Hero, 0)
+
+	rest := make([]Hero, 0, len(heroSet))
+	for _, hero := range heroSet[r.Closed:] {
+		if len(t.openLockedHeroes) < r.Open && hero.Turn != KingTurn {
+			t.openLockedHeroes = append(t.openLockedHeroes, hero)
+			continue
+		}
+		rest = append(rest, hero)
+	}
+	return rest
+}
+
+// pickOrder returns players in order of selecting heroes starting with the king,
+// second heroes are selected in reverse order by 3 players and alternately by 2 players as the official rules say
+func (t *Table) pickOrder() []*Player {
+	order := make([]*Player, 0, len(t.players)*2)
+	p := t.king
+	for range t.players {
+		order = append(order, p)
+		p = t.playerAfter(p)
+	}
+
+	switch {
+	case len(t.players) == 2:
+		order = append(order, order...)
+	case heroesPerPlayer(len(t.players)) == 2:
+		for i := len(t.players) - 1; i >= 0; i-- {
+			order = append(order, order[i])
+		}
+	}
+	return order
+}
+
+// lastSelecting reports whether the last hero of this round is being selected
+func (t *Table) lastSelecting() bool {
+	return t.pick == len(t.picks)-1
+}
+
+// protected reports whether city of p is protected by one of his heroes,
+// killed hero loses the protection
+func (t *Table) protected(p *Player) bool {
+	for _, hero := range p.Heroes {
+		if hero.Protected && hero.Turn != t.killedTurn {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,195 @@
+package citadels
+
+import (
+	"errors"
+	"strconv"
+	"testing"
+)
+
+// startedTable returns table with n players in the pick phase
+func startedTable(t *testing.T, n int) *Table {
+	table := newTable(t, HeroSetDefault)
+	for i := 0; i < n; i++ {
+		err := table.AddPlayer(NewPlayer(PlayerID("p"+strconv.Itoa(i+1)), func(Event, *Player) {}))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := table.Start()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return table
+}
+
+// TestHeroRemoval checks heroes put aside for every number of players
+func TestHeroRemoval(t *testing.T) {
+	for n := MinPlayers; n <= MaxPlayers; n++ {
+		table := startedTable(t, n)
+		table.Lock()
+		r := removals[n]
+		if len(table.openLockedHeroes) != r.Open || len(table.closedLockedHeroes) != r.Closed {
+			t.Fatal("wrong number of heroes put aside for ", n, " players")
+		}
+		if len(table.heroesToSelect) != len(table.heroes)-r.Open-r.Closed {
+			t.Fatal("rest of heroes should be selected for ", n, " players")
+		}
+		for _, hero := range table.openLockedHeroes {
+			if hero.Turn == KingTurn {
+				t.Fatal("hero with the crown should not be shown")
+			}
+		}
+		table.Unlock()
+	}
+}
+
+// TestLastTakesClosed checks that the seventh player can select the closed hero
+func TestLastTakesClosed(t *testing.T) {
+	table := startedTable(t, 7)
+	for i := 0; i < 6; i++ {
+		table.SelectHero(table.selecting, table.heroesToSelect[0].Name)
+	}
+
+	table.Lock()
+	defer table.Unlock()
+	if len(table.heroesToSelect) != len(table.heroes)-6 || len(table.closedLockedHeroes) != 0 {
+		t.Fatal("last player should choose from the remaining and the closed hero")
+	}
+}
+
+// TestPlayersLimit checks the number of players allowed by the hero set
+func TestPlayersLimit(t *testing.T) {
+	table := newTable(t, HeroSetDefault)
+	table.heroes = table.heroes[:8]
+	for i := 0; i < 7; i++ {
+		err := table.AddPlayer(NewPlayer(PlayerID("p"+strconv.Itoa(i+1)), func(Event, *Player) {}))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	if table.AddPlayer(NewPlayer("p8", func(Event, *Player) {})) != ErrTableIsFull {
+		t.Fatal("8 players should need the ninth hero")
+	}
+
+	single := newTable(t, HeroSetDefault)
+	single.AddPlayer(NewPlayer("p1", func(Event, *Player) {}))
+	if single.Start() != ErrNotEnoughPlayers {
+		t.Fatal("one player should not start the game")
+	}
+}
+
+// TestTwoHeroes checks alternate draft and two turns of every player in the game of 2 players
+func TestTwoHeroes(t *testing.T) {
+	table := startedTable(t, 2)
+	table.Lock()
+	king := table.king
+	other := table.playerAfter(king)
+	table.Unlock()
+
+	order := []*Player{king, other, king, other}
+	for _, p := range order {
+		table.Lock()
+		selecting, hero := table.selecting, table.heroesToSelect[0].Name
+		table.Unlock()
+		if selecting.ID != p.ID {
+			t.Fatal("two players should select heroes alternately")
+		}
+		table.SelectHero(p, hero)
+
+		table.Lock()
+		discarding, hero := table.discarding, table.heroesToSelect[0].Name
+		table.Unlock()
+		if discarding {
+			if err := table.DiscardHero(string(p.ID), hero); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	if len(king.Heroes) != 2 || len(other.Heroes) != 2 {
+		t.Fatal("every player should control two heroes")
+	}
+
+	turns := make(map[PlayerID]int)
+	for {
+		table.Lock()
+		phase, turn := table.currentPhase, table.turn
+		table.Unlock()
+		if phase != ActionPhase {
+			break
+		}
+		turns[turn.ID]++
+		table.EndTurn(turn.ID)
+	}
+
+	if turns[king.ID] != 2 || turns[other.ID] != 2 {
+		t.Fatal("every player should take a turn for each hero, got ", turns)
+	}
+}
+
+// TestThreePlayersOrder checks that three players select second heroes in reverse order
+func TestThreePlayersOrder(t *testing.T) {
+	table, players := newTestTable(t, Witch(), Emperor(), Warlord())
+	order := table.pickOrder()
+
+	want := []*Player{players[0], players[1], players[2], players[2], players[1], players[0]}
+	if len(order) != len(want) {
+		t.Fatal("every player should select two heroes, got ", len(order))
+	}
+	for i, p := range want {
+		if order[i].ID != p.ID {
+			t.Fatal("heroes should be selected in snake order")
+		}
+	}
+}
+
+// TestPickerDiscards checks that in the game of 2 players every pick but the first puts a hero aside face down
+func TestPickerDiscards(t *testing.T) {
+	table := startedTable(t, 2)
+	table.Lock()
+	king := table.king
+	other := table.playerAfter(king)
+	stack := len(table.heroesToSelect)
+	table.Unlock()
+
+	table.SelectHero(king, table.heroesToSelect[0].Name)
+	if table.discarding {
+		t.Fatal("the first player should not discard")
+	}
+
+	table.SelectHero(other, table.heroesToSelect[0].Name)
+	if !table.discarding || len(table.heroesToSelect) != stack-2 {
+		t.Fatal("the second player should discard after the pick")
+	}
+	if !hasCommand(table.LegalActions(other.ID), DiscardHeroCommand{Hero: table.heroesToSelect[0].Name}) {
+		t.Fatal("discard should be listed")
+	}
+	if err := table.Apply(other.ID, SelectHeroCommand{Hero: table.heroesToSelect[0].Name}); !errors.Is(err, ErrDiscardPending) {
+		t.Fatal("hero can not be selected before the discard, got ", err)
+	}
+	if err := table.DiscardHero(string(other.ID), "Nobody"); err != ErrHeroNotInStack {
+		t.Fatal("only hero from the stack is discarded, got ", err)
+	}
+	if err := table.DiscardHero(string(other.ID), table.heroesToSelect[0].Name); err != nil {
+		t.Fatal(err)
+	}
+	if err := table.DiscardHero(string(king.ID), table.heroesToSelect[0].Name); err != ErrNoDiscard {
+		t.Fatal("one hero is discarded after the pick, got ", err)
+	}
+
+	// the last player discards the hero left alone without a choice
+	for table.currentPhase == PickPhase {
+		p := table.selecting
+		if table.discarding {
+			table.DiscardHero(string(p.ID), table.heroesToSelect[0].Name)
+			continue
+		}
+		table.SelectHero(p, table.heroesToSelect[0].Name)
+	}
+	if len(table.discardedHeroes) != 3 || len(table.closedLockedHeroes) != 1 {
+		t.Fatal("three heroes should be discarded by players, got ", len(table.discardedHeroes))
+	}
+	if len(king.Heroes) != 2 || len(other.Heroes) != 2 {
+		t.Fatal("every player should control two heroes")
+	}
+}
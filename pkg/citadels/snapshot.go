@@ -0,0 +1,432 @@
+package citadels
+
+import (
+	"math/rand"
+	"sort"
+)
+
+// SnapshotVersion is version of snapshots which the engine understands
+const SnapshotVersion = 1
+
+// Snapshot is full state of the table which survives restart of the server,
+// heroes are kept by names and players by ids
+type Snapshot struct {
+	Version int          `json:"version"`
+	Options TableOptions `json:"options"`
+
+	// Draws is how many values the random source of the table has given
+	Draws uint64 `json:"draws"`
+
+	Started      bool  `json:"started"`
+	Phase        Phase `json:"phase"`
+	CurrentIndex int   `json:"current_index"`
+
+	// Players are listed by seats
+	Players []PlayerSnapshot `json:"players"`
+
+	King       PlayerID `json:"king"`
+	CrownMoved bool     `json:"crown_moved"`
+	Turn       PlayerID `json:"turn,omitempty"`
+	TurnHero   string   `json:"turn_hero,omitempty"`
+	Selecting  PlayerID `json:"selecting,omitempty"`
+
+	Picks []PlayerID `json:"picks"`
+	Pick  int        `json:"pick"`
+
+	HeroesToSelect     []string `json:"heroes_to_select"`
+	OpenLockedHeroes   []string `json:"open_locked_heroes"`
+	ClosedLockedHeroes []string `json:"closed_locked_heroes"`
+	DiscardedHeroes    []string `json:"discarded_heroes"`
+	Discarding         bool     `json:"discarding,omitempty"`
+
+	Catalog Catalog   `json:"catalog"`
+	Deck    []Quarter `json:"deck"`
+	Discard []Quarter `json:"discard"`
+
+	CompletedFirst PlayerID `json:"completed_first,omitempty"`
+
+	CustomsPool    int      `json:"customs_pool"`
+	CustomsOfficer PlayerID `json:"customs_officer,omitempty"`
+
+	Witch           PlayerID `json:"witch,omitempty"`
+	BewitchedTurn   int      `json:"bewitched_turn"`
+	BewitchedPlayer PlayerID `json:"bewitched_player,omitempty"`
+
+	Blackmailer PlayerID         `json:"blackmailer,omitempty"`
+	Threats     []ThreatSnapshot `json:"threats"`
+
+	Salvage *SalvageSnapshot `json:"salvage,omitempty"`
+
+	KilledTurn int      `json:"killed_turn"`
+	Thief      PlayerID `json:"thief,omitempty"`
+	RobbedTurn int      `json:"robbed_turn"`
+}
+
+// PlayerSnapshot is state of a player
+type PlayerSnapshot struct {
+	ID                PlayerID  `json:"id"`
+	AvailableQuarters []Quarter `json:"available_quarters"`
+	CompletedQuarters []Quarter `json:"completed_quarters"`
+	BuildChancesLeft  int       `json:"build_chances_left"`
+	Heroes            []string  `json:"heroes"`
+	Coins             int       `json:"coins"`
+	TotalScore        int       `json:"total_score"`
+
+	MadeAction    bool      `json:"made_action"`
+	SkillUsed     bool      `json:"skill_used"`
+	SpentThisTurn int       `json:"spent_this_turn"`
+	UsedQuarters  []string  `json:"used_quarters"`
+	CardsChoice   []Quarter `json:"cards_choice"`
+	CardsToKeep   int       `json:"cards_to_keep"`
+}
+
+// ThreatSnapshot is a marker of the Blackmailer
+type ThreatSnapshot struct {
+	Turn     int  `json:"turn"`
+	Real     bool `json:"real"`
+	Prompted bool `json:"prompted"`
+	Refused  bool `json:"refused"`
+	Answered bool `json:"answered"`
+}
+
+// SalvageSnapshot is destroyed quarter offered to the owner of the Graveyard
+type SalvageSnapshot struct {
+	PlayerID   PlayerID `json:"player_id"`
+	TargetID   PlayerID `json:"target_id"`
+	SalvagedBy PlayerID `json:"salvaged_by"`
+	Quarter    Quarter  `json:"quarter"`
+}
+
+// countingSource is random source which counts its values,
+// so the table can restore the source from the seed
+type countingSource struct {
+	src   rand.Source64
+	draws uint64
+}
+
+func newCountingSource(seed int64) *countingSource {
+	return &countingSource{src: rand.NewSource(seed).(rand.Source64)}
+}
+
+func (s *countingSource) Int63() int64 {
+	s.draws++
+	return s.src.Int63()
+}
+
+func (s *countingSource) Uint64() uint64 {
+	s.draws++
+	return s.src.Uint64()
+}
+
+func (s *countingSource) Seed(seed int64) {
+	s.draws = 0
+	s.src.Seed(seed)
+}
+
+// skip moves the source forward to the given number of values
+func (s *countingSource) skip(draws uint64) {
+	for s.draws < draws {
+		s.Uint64()
+	}
+}
+
+// Snapshot returns state of the table
+func (t *Table) Snapshot() Snapshot {
+	t.Lock()
+	defer t.Unlock()
+
+	s := Snapshot{
+		Version: SnapshotVersion,
+		Options: TableOptions{
+			Delays:  t.Delays,
+			HeroSet: t.heroSet,
+			Seed:    t.seed,
+			Rules:   t.rules,
+		},
+		Draws:              t.source.draws,
+		Started:            t.started,
+		Phase:              t.currentPhase,
+		CurrentIndex:       t.currentIndex,
+		Players:            make([]PlayerSnapshot, 0, len(t.seats)),
+		King:               playerID(t.king),
+		CrownMoved:         t.crownMoved,
+		Turn:               playerID(t.turn),
+		TurnHero:           t.turnHero.Name,
+		Selecting:          playerID(t.selecting),
+		Picks:              make([]PlayerID, 0, len(t.picks)),
+		Pick:               t.pick,
+		HeroesToSelect:     heroNamesOf(t.heroesToSelect),
+		OpenLockedHeroes:   heroNamesOf(t.openLockedHeroes),
+		ClosedLockedHeroes: heroNamesOf(t.closedLockedHeroes),
+		DiscardedHeroes:    heroNamesOf(t.discardedHeroes),
+		Discarding:         t.discarding,
+		Catalog:            t.catalog,
+		Deck:               append([]Quarter(nil), t.deck...),
+		Discard:            append([]Quarter(nil), t.discard...),
+		CompletedFirst:     playerID(t.completedQuartersFirst),
+		CustomsPool:        t.customsPool,
+		CustomsOfficer:     playerID(t.customsOfficer),
+		Witch:              playerID(t.witch),
+		BewitchedTurn:      t.bewitchedTurn,
+		BewitchedPlayer:    playerID(t.bewitchedPlayer),
+		Blackmailer:        playerID(t.blackmailer),
+		Threats:            make([]ThreatSnapshot, 0, len(t.threats)),
+		KilledTurn:         t.killedTurn,
+		Thief:              playerID(t.thief),
+		RobbedTurn:         t.robbedTurn,
+	}
+
+	for _, p := range t.seats {
+		p.Lock()
+		ps := PlayerSnapshot{
+			ID:                p.ID,
+			AvailableQuarters: append([]Quarter(nil), p.AvailableQuarters...),
+			CompletedQuarters: append([]Quarter(nil), p.CompletedQuarters...),
+			BuildChancesLeft:  p.BuildChancesLeft,
+			Heroes:            heroNamesOf(p.Heroes),
+			Coins:             p.Coins,
+			TotalScore:        p.totalScore,
+			MadeAction:        p.madeAction,
+			SkillUsed:         p.skillUsed,
+			SpentThisTurn:     p.spentThisTurn,
+			UsedQuarters:      make([]string, 0, len(p.usedQuarters)),
+			CardsChoice:       append([]Quarter(nil), p.currentCardsChoice...),
+			CardsToKeep:       p.cardsToKeep,
+		}
+		for name, used := range p.usedQuarters {
+			if used {
+				ps.UsedQuarters = append(ps.UsedQuarters, name)
+			}
+		}
+		sort.Strings(ps.UsedQuarters)
+		p.Unlock()
+		s.Players = append(s.Players, ps)
+	}
+
+	for _, p := range t.picks {
+		s.Picks = append(s.Picks, p.ID)
+	}
+
+	if d := t.salvageOffer; d != nil {
+		s.Salvage = &SalvageSnapshot{
+			PlayerID:   d.Player.ID,
+			TargetID:   d.Target.ID,
+			SalvagedBy: d.SalvagedBy.ID,
+			Quarter:    d.Quarter,
+		}
+	}
+
+	for turn := 1; turn <= 9; turn++ {
+		th, ok := t.threats[turn]
+		if !ok {
+			continue
+		}
+		s.Threats = append(s.Threats, ThreatSnapshot{
+			Turn:     turn,
+			Real:     th.real,
+			Prompted: th.prompted,
+			Refused:  th.refused,
+			Answered: th.answered,
+		})
+	}
+	return s
+}
+
+// RestoreTable rebuilds the table from the snapshot, players get onEvent
+// and timers of the current phase start again, the ended game is restored closed
+func RestoreTable(s Snapshot, onEvent OnEventFunc) (*Table, error) {
+	t, err := restoreTable(s, func(id PlayerID) *Player {
+		return NewPlayer(id, onEvent)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if t.currentPhase == EndGamePhase {
+		t.close()
+		close(t.done)
+		return t, nil
+	}
+
+	if t.started {
+		for _, p := range t.seats {
+			go p.Listen()
+		}
+	}
+
+	switch t.currentPhase {
+	case PickPhase:
+		t.startSelectingTimer()
+	case ActionPhase:
+		t.startTurnTimer()
+	}
+	return t, nil
+}
+
+// restoreTable rebuilds state of the table with players made by newPlayer
+func restoreTable(s Snapshot, newPlayer func(id PlayerID) *Player) (*Table, error) {
+	if s.Version != SnapshotVersion {
+		return nil, ErrUnsupportedVersion
+	}
+
+	opts := s.Options
+	opts.Recorder = nil
+	t, err := NewTable(opts)
+	if err != nil {
+		return nil, err
+	}
+	t.source.skip(s.Draws)
+
+	for _, ps := range s.Players {
+		p := newPlayer(ps.ID)
+		p.Table = t
+		p.AvailableQuarters = append(make([]Quarter, 0), ps.AvailableQuarters...)
+		p.CompletedQuarters = append(make([]Quarter, 0), ps.CompletedQuarters...)
+		p.BuildChancesLeft = ps.BuildChancesLeft
+		p.Coins = ps.Coins
+		p.totalScore = ps.TotalScore
+		p.madeAction = ps.MadeAction
+		p.skillUsed = ps.SkillUsed
+		p.spentThisTurn = ps.SpentThisTurn
+		p.currentCardsChoice = append(make([]Quarter, 0), ps.CardsChoice...)
+		p.cardsToKeep = ps.CardsToKeep
+		for _, name := range ps.UsedQuarters {
+			p.usedQuarters[name] = true
+		}
+		p.Heroes, err = t.heroesByNames(ps.Heroes)
+		if err != nil {
+			return nil, err
+		}
+
+		t.players[p.ID] = p
+		t.seats = append(t.seats, p)
+	}
+	t.reseat()
+
+	find := func(id PlayerID) (*Player, error) {
+		if id == "" {
+			return nil, nil
+		}
+		p, ok := t.players[id]
+		if !ok {
+			return nil, ErrPlayerNotExists
+		}
+		return p, nil
+	}
+	refs := []struct {
+		id PlayerID
+		p  **Player
+	}{
+		{s.King, &t.king},
+		{s.Turn, &t.turn},
+		{s.Selecting, &t.selecting},
+		{s.CompletedFirst, &t.completedQuartersFirst},
+		{s.CustomsOfficer, &t.customsOfficer},
+		{s.Witch, &t.witch},
+		{s.BewitchedPlayer, &t.bewitchedPlayer},
+		{s.Blackmailer, &t.blackmailer},
+		{s.Thief, &t.thief},
+	}
+	for _, ref := range refs {
+		*ref.p, err = find(ref.id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, id := range s.Picks {
+		p, err := find(id)
+		if err != nil {
+			return nil, err
+		}
+		t.picks = append(t.picks, p)
+	}
+
+	if t.heroesToSelect, err = t.heroesByNames(s.HeroesToSelect); err != nil {
+		return nil, err
+	}
+	if t.openLockedHeroes, err = t.heroesByNames(s.OpenLockedHeroes); err != nil {
+		return nil, err
+	}
+	if t.closedLockedHeroes, err = t.heroesByNames(s.ClosedLockedHeroes); err != nil {
+		return nil, err
+	}
+	if t.discardedHeroes, err = t.heroesByNames(s.DiscardedHeroes); err != nil {
+		return nil, err
+	}
+	if s.TurnHero != "" {
+		hero, ok := t.heroByName(s.TurnHero)
+		if !ok {
+			return nil, ErrHeroNotExists
+		}
+		t.turnHero = hero
+	}
+
+	if s.Salvage != nil {
+		d := &Destruction{Quarter: s.Salvage.Quarter}
+		for _, ref := range []struct {
+			id PlayerID
+			p  **Player
+		}{
+			{s.Salvage.PlayerID, &d.Player},
+			{s.Salvage.TargetID, &d.Target},
+			{s.Salvage.SalvagedBy, &d.SalvagedBy},
+		} {
+			if *ref.p, err = find(ref.id); err != nil || *ref.p == nil {
+				return nil, ErrPlayerNotExists
+			}
+		}
+		t.salvageOffer = d
+	}
+
+	if len(s.Threats) > 0 {
+		t.threats = make(map[int]*threat)
+	}
+	for _, th := range s.Threats {
+		t.threats[th.Turn] = &threat{real: th.Real, prompted: th.Prompted, refused: th.Refused, answered: th.Answered}
+	}
+
+	t.started = s.Started
+	t.currentPhase = s.Phase
+	t.currentIndex = s.CurrentIndex
+	t.crownMoved = s.CrownMoved
+	t.pick = s.Pick
+	t.discarding = s.Discarding
+	t.catalog = s.Catalog
+	t.deck = append(make([]Quarter, 0), s.Deck...)
+	t.discard = append(make([]Quarter, 0), s.Discard...)
+	t.customsPool = s.CustomsPool
+	t.bewitchedTurn = s.BewitchedTurn
+	t.killedTurn = s.KilledTurn
+	t.robbedTurn = s.RobbedTurn
+	return t, nil
+}
+
+// heroesByNames returns heroes of the set with given names
+func (t *Table) heroesByNames(names []string) ([]Hero, error) {
+	heroes := make([]Hero, 0, len(names))
+	for _, name := range names {
+		hero, ok := t.heroByName(name)
+		if !ok {
+			return nil, ErrHeroNotExists
+		}
+		heroes = append(heroes, hero)
+	}
+	return heroes, nil
+}
+
+// heroNamesOf returns names of heroes
+func heroNamesOf(heroes []Hero) []string {
+	names := make([]string, len(heroes))
+	for i, hero := range heroes {
+		names[i] = hero.Name
+	}
+	return names
+}
+
+// playerID returns id of p, empty if p is nil
+func playerID(p *Player) PlayerID {
+	if p == nil {
+		return ""
+	}
+	return p.ID
+}
@@ -0,0 +1,234 @@
+package citadels
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+// roundTrip restores the table from json of its snapshot
+func roundTrip(t *testing.T, table *Table) *Table {
+	data, err := json.Marshal(table.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := RestoreTable(s, func(Event, *Player) {})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return restored
+}
+
+// sameState reports whether snapshots of tables are equal
+func sameState(t *testing.T, a, b *Table) bool {
+	left, err := json.Marshal(a.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	right, err := json.Marshal(b.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bytes.Equal(left, right)
+}
+
+// TestSnapshot round-trips the table at every phase and plays on from the restored state
+func TestSnapshot(t *testing.T) {
+	table, err := NewTable(TableOptions{HeroSet: HeroSetClassic, Seed: 11})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []PlayerID{"p1", "p2", "p3", "p4"} {
+		table.AddPlayer(NewPlayer(id, func(Event, *Player) {}))
+	}
+
+	check := func(phase Phase) {
+		table.Lock()
+		current := table.currentPhase
+		table.Unlock()
+		if current != phase {
+			t.Fatal("table should be in phase ", phase, ", got ", current)
+		}
+
+		restored := roundTrip(t, table)
+		if !sameState(t, table, restored) {
+			t.Fatal("restored table should have the same state in phase ", phase)
+		}
+		for i := 0; i < 20; i++ {
+			botStep(table)
+			botStep(restored)
+		}
+		if !sameState(t, table, restored) {
+			t.Fatal("restored table should play the same game from phase ", phase)
+		}
+	}
+
+	check(PreGamePhase)
+	table.Start()
+	check(PickPhase)
+
+	var turn *Player
+	for i := 0; i < 10; i++ {
+		table.Lock()
+		current := table.currentPhase
+		turn = table.turn
+		table.Unlock()
+		if current == ActionPhase {
+			break
+		}
+		botStep(table)
+	}
+	table.MakeAction(ActionTypeCards, string(turn.ID))
+	check(ActionPhase)
+
+	for botStep(table) {
+	}
+	check(EndGamePhase)
+
+	// the ended game is restored without listening players
+	ended := roundTrip(t, table)
+	select {
+	case <-ended.Ended():
+	default:
+		t.Fatal("restored table should be ended")
+	}
+	for _, p := range ended.seats {
+		if _, ok := <-p.updates; ok {
+			t.Fatal("updates of players should be closed in the ended game")
+		}
+	}
+
+	if _, err := RestoreTable(Snapshot{Version: 2}, nil); err != ErrUnsupportedVersion {
+		t.Fatal("unknown version should be refused, got ", err)
+	}
+}
+
+// restoredCopy restores the table from json of its snapshot with players of small buffers
+func restoredCopy(t *testing.T, table *Table) *Table {
+	data, err := json.Marshal(table.Snapshot())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := restoreTable(s, func(id PlayerID) *Player {
+		return newPlayer(id, func(Event, *Player) {}, 1000)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	restored.noTimers = true
+	return restored
+}
+
+// move is command of the player with the index
+type move struct {
+	player int
+	cmd    TableCommand
+}
+
+// TestSnapshotDefault restores the default set in the middle of skills and plays on
+func TestSnapshotDefault(t *testing.T) {
+	tests := []struct {
+		name   string
+		heroes []Hero
+		moves  func(table *Table, players []*Player) []move
+	}{
+		{
+			name:   "threat",
+			heroes: []Hero{Witch(), Blackmailer(), Enchantress(), Abat()},
+			moves: func(table *Table, players []*Player) []move {
+				table.currentIndex = 1
+				players[2].AddCoins(4)
+				return []move{
+					{1, CastSkillCommand{Data: EventBlackmailerSkill{RealHero: "Enchantress", BluffHero: "Abat"}}},
+					{1, EndTurnCommand{}},
+					{2, MakeActionCommand{Action: ActionTypeCoin}},
+					{2, PayThreatCommand{Pay: false}},
+					{1, RevealThreatCommand{Reveal: true}},
+					{2, EndTurnCommand{}},
+				}
+			},
+		},
+		{
+			name:   "bewitched",
+			heroes: []Hero{Witch(), Blackmailer(), Emperor(), Warlord()},
+			moves: func(table *Table, players []*Player) []move {
+				players[3].AddCoins(2)
+				return []move{
+					{0, CastSkillCommand{Data: EventWitchSkill{HeroName: "Emperor"}}},
+					{1, EndTurnCommand{}},
+					{2, MakeActionCommand{Action: ActionTypeCoin}},
+					{0, CastSkillCommand{Data: EventEmperorSkill{TargetID: players[3].ID, Coin: true}}},
+					{0, EndTurnCommand{}},
+				}
+			},
+		},
+		{
+			name:   "salvage",
+			heroes: []Hero{Alchemist(), Architect(), Warlord(), CustomsOfficer()},
+			moves: func(table *Table, players []*Player) []move {
+				table.currentIndex = 7
+				players[2].AddCoins(10)
+				players[0].CompletedQuarters = []Quarter{{Name: "castle", Type: QuarterTypeNoble, Price: 4}}
+				players[1].CompletedQuarters = []Quarter{special(QuarterGraveyard, 5)}
+				players[1].AddCoins(1)
+				return []move{
+					{2, CastSkillCommand{Data: EventWarlordSkill{TargetID: players[0].ID, Quarter: "castle"}}},
+					{1, SalvageCommand{Take: true}},
+					{2, EndTurnCommand{}},
+				}
+			},
+		},
+		{
+			name:   "customs",
+			heroes: []Hero{Alchemist(), Architect(), Warlord(), CustomsOfficer()},
+			moves: func(table *Table, players []*Player) []move {
+				players[0].AddCoins(3)
+				players[0].AvailableQuarters = table.drawFromDeck(1)
+				players[3].AddCoins(1)
+				players[3].AvailableQuarters = table.drawFromDeck(1)
+				return []move{
+					{0, BuildCommand{Quarter: players[0].AvailableQuarters[0].Name}},
+					{0, EndTurnCommand{}},
+					{1, EndTurnCommand{}},
+					{2, EndTurnCommand{}},
+					{3, BuildCommand{Quarter: players[3].AvailableQuarters[0].Name}},
+					{3, EndTurnCommand{}},
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, players := newTestTable(t, tt.heroes...)
+			table.noTimers = true
+			moves := tt.moves(table, players)
+			table.startActionPhase()
+
+			for i, m := range moves {
+				restored := restoredCopy(t, table)
+				if !sameState(t, table, restored) {
+					t.Fatal("restored table should have the same state before move ", i)
+				}
+				id := players[m.player].ID
+				if err := table.Apply(id, m.cmd); err != nil {
+					t.Fatal(err)
+				}
+				if err := restored.Apply(id, m.cmd); err != nil {
+					t.Fatal("restored table should accept move ", i, ", got ", err)
+				}
+				if !sameState(t, table, restored) {
+					t.Fatal("restored table should play move ", i, " the same way")
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,302 @@
+package citadels
+
+// Names of special quarters with abilities
+const (
+	QuarterKeep           = "Keep"
+	QuarterLibrary        = "Library"
+	QuarterObservatory    = "Observatory"
+	QuarterLaboratory     = "Laboratory"
+	QuarterSmithy         = "Smithy"
+	QuarterHauntedQuarter = "Haunted Quarter"
+	QuarterSchoolOfMagic  = "School of Magic"
+	QuarterDragonGate     = "Dragon Gate"
+	QuarterGreatWall      = "Great Wall"
+	QuarterGraveyard      = "Graveyard"
+)
+
+// Income is what Player gets for the resource action of his turn
+type Income struct {
+	// Coins are given for ActionTypeCoin
+	Coins int
+
+	// Draw is how many cards are drawn for ActionTypeCards
+	Draw int
+
+	// Keep is how many of the drawn cards player chooses
+	Keep int
+
+	// KeepAll lets player keep every drawn card instead of choosing
+	KeepAll bool
+}
+
+// Destruction describes a quarter which the Warlord is destroying
+type Destruction struct {
+	// Player is who destroys the quarter
+	Player *Player
+
+	// Target is owner of the quarter
+	Target  *Player
+	Quarter Quarter
+
+	// Cost is how many coins the destruction takes
+	Cost int
+
+	// SalvagedBy is offered to take destroyed quarter to his hand for a coin instead of the discard pile
+	SalvagedBy *Player
+}
+
+// QuarterAbility is behaviour of a completed quarter, every hook is optional
+// and owner is player who has the quarter in his city
+type QuarterAbility struct {
+	// OnBuild is called when owner builds the quarter
+	OnBuild func(t *Table, owner *Player, q Quarter)
+
+	// OnIncome is called when owner takes the resource action and can change the income
+	OnIncome func(t *Table, owner *Player, inc *Income)
+
+	// OnTurnEnd is called at the end of owner's turn
+	OnTurnEnd func(t *Table, owner *Player)
+
+	// OnDestroy is called for quarters of every city when any quarter is being destroyed,
+	// it can change the destruction or forbid it with an error
+	OnDestroy func(t *Table, owner *Player, q Quarter, d *Destruction) error
+
+	// OnScore returns extra points of the quarter at the end of the game
+	OnScore func(t *Table, owner *Player) int
+
+	// Activate is called when owner uses the quarter during his turn, once per turn
+	Activate CastFunc
+
+	// Targets lists every data which Activate accepts right now
+	Targets TargetsFunc
+
+	// AnyTypeForIncome makes the quarter count as any type for income of heroes
+	AnyTypeForIncome bool
+
+	// AnyTypeForScore makes the quarter count as any type for the end game bonus
+	AnyTypeForScore bool
+}
+
+var quarterAbilities map[string]QuarterAbility
+
+func init() {
+	quarterAbilities = map[string]QuarterAbility{
+		QuarterKeep: {
+			OnDestroy: func(t *Table, owner *Player, q Quarter, d *Destruction) error {
+				if d.Target.ID == owner.ID && d.Quarter.Name == q.Name {
+					return ErrQuarterIndestructible
+				}
+				return nil
+			},
+		},
+		QuarterLibrary: {
+			OnIncome: func(t *Table, owner *Player, inc *Income) {
+				inc.KeepAll = true
+			},
+		},
+		QuarterObservatory: {
+			OnIncome: func(t *Table, owner *Player, inc *Income) {
+				inc.Draw++
+			},
+		},
+		QuarterLaboratory: {
+			Activate: func(t *Table, owner *Player, ev Event) error {
+				var e EventCardName
+				err := decodeEventData(ev, &e)
+				if err != nil {
+					return err
+				}
+
+				card, ok := owner.takeFromHand(e.Card)
+				if !ok {
+					return ErrCardNotInHand
+				}
+				t.discard = append(t.discard, card)
+				owner.AddCoins(1)
+
+				t.doBroadcastEvent(Event{
+					Type: EventTypeCoinsGive,
+					Data: EventCoinGive{
+						To:     owner.ID,
+						Amount: 1,
+						Sum:    owner.Coins,
+					},
+				})
+				return nil
+			},
+			Targets: func(t *Table, owner *Player) []interface{} {
+				targets := make([]interface{}, 0)
+				for _, name := range distinctNames(owner.AvailableQuarters) {
+					targets = append(targets, EventCardName{Card: name})
+				}
+				return targets
+			},
+		},
+		QuarterSmithy: {
+			Activate: func(t *Table, owner *Player, ev Event) error {
+				if !emptyEventData(ev) {
+					return ErrWrongEventData
+				}
+				if owner.Coins < 2 {
+					return ErrNotEnoughCoins
+				}
+				owner.AddCoins(-2)
+				t.doBroadcastEvent(Event{
+					Type: EventTypeCoinsPaid,
+					Data: EventCoinsPaid{
+						PlayerID: owner.ID,
+						Amount:   2,
+						Sum:      owner.Coins,
+					},
+				})
+				t.giveCards(owner, t.drawFromDeck(3))
+				return nil
+			},
+			// Smithy takes no data
+			Targets: func(t *Table, owner *Player) []interface{} {
+				if owner.Coins < 2 {
+					return nil
+				}
+				return []interface{}{nil}
+			},
+		},
+		QuarterHauntedQuarter: {
+			AnyTypeForScore: true,
+		},
+		QuarterSchoolOfMagic: {
+			AnyTypeForIncome: true,
+		},
+		QuarterDragonGate: {
+			OnScore: func(t *Table, owner *Player) int {
+				return 2
+			},
+		},
+		QuarterGreatWall: {
+			OnDestroy: func(t *Table, owner *Player, q Quarter, d *Destruction) error {
+				if d.Target.ID == owner.ID && d.Quarter.Name != q.Name {
+					d.Cost++
+				}
+				return nil
+			},
+		},
+		QuarterGraveyard: {
+			OnDestroy: func(t *Table, owner *Player, q Quarter, d *Destruction) error {
+				if owner.ID == d.Player.ID || d.Quarter.Name == q.Name || d.SalvagedBy != nil {
+					return nil
+				}
+				d.SalvagedBy = owner
+				return nil
+			},
+		},
+	}
+}
+
+// RegisterQuarterAbility gives behaviour to quarters with the name
+func RegisterQuarterAbility(name string, ability QuarterAbility) {
+	contentMu.Lock()
+	defer contentMu.Unlock()
+	quarterAbilities[name] = ability
+}
+
+// abilityOf returns behaviour of the quarter
+func abilityOf(q Quarter) (QuarterAbility, bool) {
+	contentMu.RLock()
+	defer contentMu.RUnlock()
+	ability, ok := quarterAbilities[q.Name]
+	return ability, ok
+}
+
+// quarterHooks calls do for every completed quarter of p which has an ability
+func quarterHooks(p *Player, do func(q Quarter, ability QuarterAbility)) {
+	for _, q := range append([]Quarter(nil), p.CompletedQuarters...) {
+		if ability, ok := abilityOf(q); ok {
+			do(q, ability)
+		}
+	}
+}
+
+// giveCards adds cards to Player.AvailableQuarters and tells everyone how many were drawn
+func (t *Table) giveCards(p *Player, cards []Quarter) {
+	for _, card := range cards {
+		p.AddQuarter(card)
+	}
+
+	p.Notify(Event{
+		Type: EventTypeDrawCards,
+		Data: EventCards{Cards: cards},
+	})
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypePlayerDrewCards,
+		Data: EventPlayerDrewCards{
+			PlayerID: p.ID,
+			Count:    len(cards),
+		},
+	})
+}
+
+// UseQuarter activates ability of a completed quarter during the turn of its owner
+func (t *Table) UseQuarter(pID string, quarterName string, ev Event) error {
+	t.Lock()
+	defer t.Unlock()
+	return t.record(Command{Type: CommandTypeUseQuarter, PlayerID: PlayerID(pID), Quarter: quarterName, Data: commandData(ev)}, func() error {
+		return t.useQuarter(PlayerID(pID), quarterName, ev)
+	})
+}
+
+// useQuarter activates ability of the quarter
+func (t *Table) useQuarter(pID PlayerID, quarterName string, ev Event) error {
+	p, ok := t.players[pID]
+	if !ok {
+		return ErrPlayerNotExists
+	}
+
+	ability, err := t.checkUseQuarter(p, quarterName)
+	if err != nil {
+		return err
+	}
+
+	err = ability.Activate(t, p, ev)
+	if err != nil {
+		return err
+	}
+	p.usedQuarters[quarterName] = true
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeQuarterUsed,
+		Data: EventQuarterUsed{
+			PlayerID: p.ID,
+			Quarter:  quarterName,
+		},
+	})
+	return nil
+}
+
+// checkUseQuarter returns ability of the quarter or why p can not use it whatever data he sends
+func (t *Table) checkUseQuarter(p *Player, quarterName string) (QuarterAbility, error) {
+	if t.currentPhase != ActionPhase || t.turn.ID != p.ID {
+		return QuarterAbility{}, ErrNotYourTurn
+	}
+
+	if t.isBewitchedTurn() {
+		return QuarterAbility{}, ErrPlayerBewitched
+	}
+
+	if t.threatUnresolved() {
+		return QuarterAbility{}, ErrThreatUnresolved
+	}
+
+	if !p.builtQuarter(quarterName) {
+		return QuarterAbility{}, ErrQuarterNotExists
+	}
+
+	ability, ok := abilityOf(Quarter{Name: quarterName})
+	if !ok || ability.Activate == nil {
+		return QuarterAbility{}, ErrNoActiveSkill
+	}
+
+	if p.usedQuarters[quarterName] {
+		return QuarterAbility{}, ErrSkillAlreadyUsed
+	}
+	return ability, nil
+}
@@ -0,0 +1,276 @@
+package citadels
+
+import (
+	"errors"
+	"testing"
+)
+
+// special returns special quarter with given name and price
+func special(name string, price int) Quarter {
+	return Quarter{Name: name, Type: QuarterTypeSpecial, Price: price}
+}
+
+// TestLibrary checks that owner keeps both drawn cards
+func TestLibrary(t *testing.T) {
+	table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+	alchemist := players[0]
+	alchemist.CompletedQuarters = []Quarter{special(QuarterLibrary, 6)}
+
+	table.startActionPhase()
+	table.MakeAction(ActionTypeCards, string(alchemist.ID))
+	if len(alchemist.AvailableQuarters) != 2 || len(alchemist.currentCardsChoice) != 0 {
+		t.Fatal("library owner should keep both cards")
+	}
+	if !hasEvent(drainEvents(players[1]), EventTypePlayerDrewCards) {
+		t.Fatal("drawn cards should be announced")
+	}
+}
+
+// TestObservatory checks that owner chooses one of three cards
+func TestObservatory(t *testing.T) {
+	table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+	alchemist := players[0]
+	alchemist.CompletedQuarters = []Quarter{special(QuarterObservatory, 5)}
+
+	table.startActionPhase()
+	table.MakeAction(ActionTypeCards, string(alchemist.ID))
+	if len(alchemist.currentCardsChoice) != 3 {
+		t.Fatal("observatory owner should choose from three cards")
+	}
+
+	deck := len(table.deck)
+	table.SelectCard(alchemist.currentCardsChoice[1].Name, string(alchemist.ID))
+	if len(alchemist.AvailableQuarters) != 1 || len(table.deck) != deck+2 {
+		t.Fatal("unchosen cards should go back to the deck")
+	}
+}
+
+// TestLaboratory checks discarding a card for a coin
+func TestLaboratory(t *testing.T) {
+	table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+	alchemist, architect := players[0], players[1]
+	alchemist.CompletedQuarters = []Quarter{special(QuarterLaboratory, 5)}
+	alchemist.AvailableQuarters = table.drawFromDeck(2)
+	architect.CompletedQuarters = []Quarter{special(QuarterLaboratory, 5)}
+
+	table.startActionPhase()
+	card := alchemist.AvailableQuarters[0].Name
+	use := func(p *Player, card string) error {
+		return table.UseQuarter(string(p.ID), QuarterLaboratory, Event{Data: EventCardName{Card: card}})
+	}
+
+	if err := use(architect, card); err != ErrNotYourTurn {
+		t.Fatal("quarter should be used during owner's turn, got ", err)
+	}
+	if err := use(alchemist, "unknown"); err != ErrCardNotInHand {
+		t.Fatal("card should be in hand, got ", err)
+	}
+	if err := use(alchemist, card); err != nil {
+		t.Fatal(err)
+	}
+	if alchemist.Coins != 1 || len(alchemist.AvailableQuarters) != 1 || len(table.discard) != 1 {
+		t.Fatal("card should be discarded for a coin")
+	}
+	if err := use(alchemist, alchemist.AvailableQuarters[0].Name); err != ErrSkillAlreadyUsed {
+		t.Fatal("laboratory should be used once per turn, got ", err)
+	}
+	if !hasEvent(drainEvents(architect), EventTypeQuarterUsed) {
+		t.Fatal("quarter usage should be announced")
+	}
+	if err := table.UseQuarter(string(alchemist.ID), QuarterKeep, Event{}); err != ErrQuarterNotExists {
+		t.Fatal("only built quarters should be used, got ", err)
+	}
+}
+
+// TestSmithy checks buying three cards for two coins
+func TestSmithy(t *testing.T) {
+	table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+	alchemist := players[0]
+	alchemist.CompletedQuarters = []Quarter{special(QuarterSmithy, 5)}
+	alchemist.AddCoins(1)
+
+	table.startActionPhase()
+	if err := table.UseQuarter(string(alchemist.ID), QuarterSmithy, Event{}); err != ErrNotEnoughCoins {
+		t.Fatal("smithy should cost two coins, got ", err)
+	}
+
+	alchemist.AddCoins(1)
+	if err := table.UseQuarter(string(alchemist.ID), QuarterSmithy, Event{}); err != nil {
+		t.Fatal(err)
+	}
+	if alchemist.Coins != 0 || len(alchemist.AvailableQuarters) != 3 {
+		t.Fatal("smithy owner should draw three cards")
+	}
+
+	if err := table.UseQuarter(string(alchemist.ID), QuarterSmithy, Event{}); err != ErrSkillAlreadyUsed {
+		t.Fatal("smithy should be used once per turn, got ", err)
+	}
+}
+
+// TestSchoolOfMagic checks that the quarter brings income of any type
+func TestSchoolOfMagic(t *testing.T) {
+	table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+	warlord := players[2]
+	warlord.CompletedQuarters = []Quarter{
+		{Name: "fortress", Type: QuarterTypeMilitary, Price: 5},
+		special(QuarterSchoolOfMagic, 6),
+	}
+
+	table.currentIndex = 7
+	table.startActionPhase()
+	if warlord.Coins != 2 {
+		t.Fatal("school of magic should count as a Military quarter")
+	}
+}
+
+// TestHauntedQuarter checks that the quarter counts as any type for the bonus
+func TestHauntedQuarter(t *testing.T) {
+	p := NewPlayer("p1", func(Event, *Player) {})
+	p.CompletedQuarters = []Quarter{
+		{Name: "fortress", Type: QuarterTypeMilitary, Price: 5},
+		{Name: "castle", Type: QuarterTypeNoble, Price: 4},
+		{Name: "temple", Type: QuarterTypeSpiritual, Price: 1},
+		{Name: "tavern", Type: QuarterTypeTrade, Price: 1},
+		special(QuarterHauntedQuarter, 2),
+	}
+	if p.districtTypes() != 5 {
+		t.Fatal("haunted quarter should count as its own type")
+	}
+
+	p.CompletedQuarters[4] = special(QuarterKeep, 3)
+	p.CompletedQuarters[3] = special(QuarterHauntedQuarter, 2)
+	if p.districtTypes() != 5 {
+		t.Fatal("haunted quarter should count as a missing type")
+	}
+}
+
+// TestScoreQuarters checks extra points of Dragon Gate
+func TestScoreQuarters(t *testing.T) {
+	table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+	alchemist, architect := players[0], players[1]
+	alchemist.CompletedQuarters = table.drawFromDeck(table.rules.CitySize)
+	architect.CompletedQuarters = []Quarter{special(QuarterDragonGate, 6)}
+	table.completedQuartersFirst = alchemist
+
+	table.endRound()
+	if architect.totalScore != 8 {
+		t.Fatal("dragon gate should be worth 8 points, got ", architect.totalScore)
+	}
+}
+
+// TestDestroySpecial checks Keep, Great Wall and Graveyard against the Warlord
+func TestDestroySpecial(t *testing.T) {
+	table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+	alchemist, architect, warlord := players[0], players[1], players[2]
+	warlord.AddCoins(10)
+	alchemist.CompletedQuarters = []Quarter{
+		special(QuarterKeep, 3),
+		special(QuarterGreatWall, 6),
+		{Name: "castle", Type: QuarterTypeNoble, Price: 4},
+	}
+	architect.CompletedQuarters = []Quarter{special(QuarterGraveyard, 5)}
+	architect.AddCoins(1)
+
+	table.currentIndex = 7
+	table.startActionPhase()
+	cast := func(quarter string) error {
+		return table.CastSkill(string(warlord.ID), Event{Data: EventWarlordSkill{TargetID: alchemist.ID, Quarter: quarter}})
+	}
+
+	if err := cast(QuarterKeep); err != ErrQuarterIndestructible {
+		t.Fatal("keep should not be destroyed, got ", err)
+	}
+	if err := cast("castle"); err != nil {
+		t.Fatal(err)
+	}
+	if warlord.Coins != 6 {
+		t.Fatal("great wall should make destruction one coin more expensive")
+	}
+	if !hasEvent(drainEvents(architect), EventTypeChooseSalvage) {
+		t.Fatal("graveyard owner should be offered the destroyed quarter")
+	}
+	if err := table.Apply(warlord.ID, EndTurnCommand{}); !errors.Is(err, ErrSalvageUnresolved) {
+		t.Fatal("warlord should wait for the graveyard owner, got ", err)
+	}
+	if err := table.Salvage(string(alchemist.ID), true); err != ErrNoSalvage {
+		t.Fatal("only graveyard owner answers the offer, got ", err)
+	}
+	if err := table.Salvage(string(architect.ID), true); err != nil {
+		t.Fatal(err)
+	}
+	if architect.Coins != 0 || len(architect.AvailableQuarters) != 1 || len(table.discard) != 0 {
+		t.Fatal("graveyard owner should take the destroyed quarter for a coin")
+	}
+	if !hasEvent(drainEvents(alchemist), EventTypeQuarterSalvaged) {
+		t.Fatal("salvage should be announced")
+	}
+	if err := table.Apply(warlord.ID, EndTurnCommand{}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// TestDeclineSalvage checks that graveyard owner may leave destroyed quarter in the discard pile
+func TestDeclineSalvage(t *testing.T) {
+	for _, answer := range []bool{true, false} {
+		table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+		alchemist, architect, warlord := players[0], players[1], players[2]
+		warlord.AddCoins(10)
+		alchemist.CompletedQuarters = []Quarter{{Name: "castle", Type: QuarterTypeNoble, Price: 4}}
+		architect.CompletedQuarters = []Quarter{special(QuarterGraveyard, 5)}
+		architect.AddCoins(1)
+
+		table.currentIndex = 7
+		table.startActionPhase()
+		if err := table.CastSkill(string(warlord.ID), Event{Data: EventWarlordSkill{TargetID: alchemist.ID, Quarter: "castle"}}); err != nil {
+			t.Fatal(err)
+		}
+
+		// the owner declines or keeps silent until the Warlord's turn is over
+		if answer {
+			if err := table.Salvage(string(architect.ID), false); err != nil {
+				t.Fatal(err)
+			}
+		} else {
+			table.endTurn()
+		}
+		if architect.Coins != 1 || len(architect.AvailableQuarters) != 0 || len(table.discard) != 1 {
+			t.Fatal("declined quarter should go to the discard pile")
+		}
+		if !hasEvent(drainEvents(alchemist), EventTypeSalvageDeclined) {
+			t.Fatal("declined salvage should be announced")
+		}
+		if err := table.Salvage(string(architect.ID), true); err != ErrNoSalvage {
+			t.Fatal("the offer is answered only once, got ", err)
+		}
+	}
+}
+
+// TestRegisterQuarterAbility checks build and turn end hooks of custom quarters
+func TestRegisterQuarterAbility(t *testing.T) {
+	var built, ended int
+	RegisterQuarterAbility("Fountain", QuarterAbility{
+		OnBuild: func(t *Table, owner *Player, q Quarter) {
+			built++
+			owner.AddCoins(1)
+		},
+		OnTurnEnd: func(t *Table, owner *Player) {
+			ended++
+		},
+	})
+
+	table, players := newTestTable(t, Alchemist(), Architect(), Warlord(), CustomsOfficer())
+	alchemist := players[0]
+	alchemist.AvailableQuarters = []Quarter{special("Fountain", 1)}
+	alchemist.AddCoins(1)
+
+	table.startActionPhase()
+	table.BuildQuarter(alchemist.AvailableQuarters[0], string(alchemist.ID))
+	if built != 1 || alchemist.Coins != 1 {
+		t.Fatal("build hook should be called")
+	}
+
+	table.EndTurn(alchemist.ID)
+	if ended != 1 {
+		t.Fatal("turn end hook should be called once, got ", ended)
+	}
+}
@@ -4,20 +4,15 @@ package citadels
 
 import (
 	"math/rand"
-	"strconv"
 	"sync"
 	"time"
 )
 
-func init() {
-	rand.Seed(time.Now().UnixNano())
-}
-
 const (
 	// MaxPlayers is max number of players in 1 room
-	MaxPlayers = 4
+	MaxPlayers = 8
 	// MinPlayers is min number of players in 1 room
-	MinPlayers = 4
+	MinPlayers = 2
 )
 
 // Phase represents separate logic cycles in game
@@ -35,10 +30,6 @@ const (
 	PreGamePhase Phase = "citadels.phase.pregame"
 )
 
-var heroSets = map[string][]Hero{
-	"default": {Witch(), Blackmailer(), Enchantress(), Emperor(), Abat(), Alchemist(), Architect(), Warlord(), CustomsOfficer()},
-}
-
 // Table represents a game table (also known as Room)
 type Table struct {
 	sync.Mutex
@@ -46,12 +37,19 @@ type Table struct {
 	// king is player which starts PickPhase
 	king *Player
 
+	// crownMoved shows whether the crown changed its owner this round
+	crownMoved bool
+
 	// turn is player who is currently taking a turn
 	turn *Player
 
 	// selecting is Player who selecting Hero right now
 	selecting *Player
 
+	// picks are players in order of selecting heroes this round, pick is index of selecting one
+	picks []*Player
+	pick  int
+
 	started bool
 
 	currentPhase Phase
@@ -60,6 +58,9 @@ type Table struct {
 
 	heroSet string
 
+	// heroes are heroes of the set which the table plays with
+	heroes []Hero
+
 	completedQuartersFirst *Player
 
 	// heroesToSelect is map of remaining heroes
@@ -71,26 +72,146 @@ type Table struct {
 	openLockedHeroes   []Hero
 	closedLockedHeroes []Hero
 
+	// discardedHeroes are put aside face down by selecting players, hero i by picks[i+1]
+	discardedHeroes []Hero
+
+	// discarding shows that selecting player has to put a hero aside before the next pick
+	discarding bool
+
 	deck []Quarter
 
+	// catalog is what the deck is made of
+	catalog Catalog
+
+	// discard is pile of destroyed quarters
+	discard []Quarter
+
+	// customsPool is tax put on the Customs Officer card before he is revealed
+	customsPool int
+
+	// customsOfficer is Player who collects tax after the Customs Officer is revealed
+	customsOfficer *Player
+
+	// turnHero is hero whose skill is used in the current turn
+	// it differs from turn.Hero when the Witch takes over a bewitched hero
+	turnHero Hero
+
+	// witch is Player who bewitched a hero this round
+	witch *Player
+
+	// bewitchedTurn is turn of the hero named by the Witch, 0 if nobody is bewitched
+	bewitchedTurn int
+
+	// bewitchedPlayer is Player whose hero was bewitched, known once his turn has come
 	bewitchedPlayer *Player
 
+	// blackmailer is Player who placed threat markers this round
+	blackmailer *Player
+
+	// threats are markers of the Blackmailer by turn of threatened hero
+	threats map[int]*threat
+
+	// salvageOffer is destroyed quarter which waits for the answer of Destruction.SalvagedBy
+	salvageOffer *Destruction
+
+	// killedTurn is turn of the hero killed by the Assassin, 0 if nobody is killed
+	killedTurn int
+
+	// thief is Player who robs a hero this round
+	thief *Player
+
+	// robbedTurn is turn of the hero robbed by the Thief, 0 if nobody is robbed
+	robbedTurn int
+
 	players map[PlayerID]*Player
 
+	// seats are players clockwise, Player.Order is seat number starting with 1
+	seats []*Player
+
+	// rules are numbers of the game chosen on creation
+	rules RuleSet
+
+	// rng makes every random choice of the table, same seed and commands give the same game
+	rng    *rand.Rand
+	source *countingSource
+	seed   int64
+
+	// recorder logs commands and events, nil if the game is not recorded
+	recorder *Recorder
+
 	Delays bool
 
+	// noTimers keeps the table from ending turns of inactive players, copies made to try commands have no timers
+	noTimers bool
+
 	done chan struct{}
 }
 
-func NewTable(delay bool) *Table {
-	return &Table{
+// NewTable creates a table with given options, the hero set and the catalog have to be registered before
+// and rules have to be playable
+func NewTable(opts TableOptions) (*Table, error) {
+	heroes, ok := HeroSetByID(opts.HeroSet)
+	if !ok {
+		return nil, ErrHeroSetNotExists
+	}
+
+	catalog := ClassicCatalog()
+	if opts.Catalog != "" {
+		catalog, ok = CatalogByID(opts.Catalog)
+		if !ok {
+			return nil, ErrCatalogNotExists
+		}
+	}
+
+	rules := opts.Rules
+	if rules == (RuleSet{}) {
+		rules = OfficialRules()
+	}
+	err := rules.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	seed := opts.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	source := newCountingSource(seed)
+	t := &Table{
 		players:      make(map[PlayerID]*Player),
 		currentPhase: PreGamePhase,
-		Delays:       delay,
+		heroSet:      opts.HeroSet,
+		heroes:       heroes,
+		catalog:      catalog,
+		rules:        rules,
+		rng:          rand.New(source),
+		source:       source,
+		seed:         seed,
+		recorder:     opts.Recorder,
+		Delays:       opts.Delays,
 		done:         make(chan struct{}),
 	}
+
+	if t.recorder != nil {
+		opts.Seed = seed
+		opts.Rules = rules
+		t.recorder.write(Record{Table: &opts})
+	}
+	return t, nil
 }
 
+// Rules returns rules which the table plays by
+func (t *Table) Rules() RuleSet {
+	return t.rules
+}
+
+// Seed returns value which randomness of the table comes from
+func (t *Table) Seed() int64 {
+	return t.seed
+}
+
+// Ended returns channel which is closed when the game ends
 func (t *Table) Ended() <-chan struct{} {
 	return t.done
 }
@@ -105,36 +226,35 @@ func (t *Table) close() {
 func (t *Table) Start() error {
 	t.Lock()
 	defer t.Unlock()
+	return t.record(Command{Type: CommandTypeStart}, t.start)
+}
+
+// start deals the first round, returns why the table can not start
+func (t *Table) start() error {
 	if t.started {
 		return ErrTableAlreadyStarted
 	}
 	if len(t.players) < MinPlayers {
 		return ErrNotEnoughPlayers
 	}
-	if len(t.players) > MaxPlayers {
+	if len(t.heroes) == 0 {
+		return ErrHeroSetNotExists
+	}
+	if len(t.players) > t.maxPlayers() {
 		return ErrTableIsFull
 	}
 
-	var i int = 1
-	for _, player := range t.players {
-		player.Order = i
-		i++
+	for _, player := range t.seats {
 		go player.Listen()
 	}
 
-	// TODO: rethink in future
-	t.heroSet = "default"
-
 	// makes random player a king
-	for _, p := range t.players {
-		t.king = p
-		break
-	}
+	t.king = t.seats[t.rng.Intn(len(t.seats))]
 
 	t.doBroadcastEvent(Event{
 		Type: EventTypeRevealHeroSet,
 		Data: EventHeroSet{
-			HeroSet: heroSets[t.heroSet],
+			HeroSet: t.heroes,
 		},
 	})
 
@@ -148,7 +268,9 @@ func (t *Table) Start() error {
 	t.doBroadcastEvent(Event{
 		Type: EventTypeGameStarted,
 		Data: EventGameStarted{
-			King: t.king,
+			King:  t.king.ID,
+			Rules: t.rules,
+			Seats: t.seatIDs(),
 		},
 	})
 
@@ -158,23 +280,12 @@ func (t *Table) Start() error {
 }
 
 func (t *Table) drawCards() {
-	// TODO: fill the deck normally
-	deck := make([]Quarter, 100)
-	types := []string{
-		QuarterTypeMilitary, QuarterTypeSpecial, QuarterTypeNoble, QuarterTypeSpiritual, QuarterTypeTrade,
-	}
-	for i := 0; i < 100; i++ {
-		deck[i] = Quarter{
-			Name:  strconv.Itoa(rand.Intn(10000000)),
-			Type:  types[rand.Intn(5)],
-			Price: 1,
-			//Price: rand.Intn(5)+1,
-		}
-	}
-	t.deck = deck
-	for _, p := range t.players {
-		p.AvailableQuarters = t.deck[:4]
-		t.deck = t.deck[4:]
+	t.deck = t.catalog.Deck()
+	t.rng.Shuffle(len(t.deck), func(i, j int) { t.deck[i], t.deck[j] = t.deck[j], t.deck[i] })
+
+	for _, p := range t.seated() {
+		p.AvailableQuarters = t.drawFromDeck(t.rules.StartingHand)
+		p.AddCoins(t.rules.StartingGold)
 		p.Notify(Event{
 			Type: EventTypeDrawCards,
 			Data: EventCards{Cards: p.AvailableQuarters},
@@ -182,27 +293,126 @@ func (t *Table) drawCards() {
 	}
 }
 
-func (t *Table) startPickPhase() {
-	t.currentPhase = PickPhase
+// SetCatalog changes quarters which the deck is made of, works only before the start
+func (t *Table) SetCatalog(c Catalog) error {
+	t.Lock()
+	defer t.Unlock()
+	return t.record(Command{Type: CommandTypeSetCatalog, Catalog: &c}, func() error {
+		return t.setCatalog(c)
+	})
+}
 
-	heroSet := make([]Hero, len(heroSets[t.heroSet]))
-	copy(heroSet, heroSets[t.heroSet])
+// setCatalog changes the catalog of the deck
+func (t *Table) setCatalog(c Catalog) error {
+	if t.started {
+		return ErrTableAlreadyStarted
+	}
+	t.catalog = c
+	return nil
+}
 
-	rand.Shuffle(len(heroSet), func(i, j int) { heroSet[i], heroSet[j] = heroSet[j], heroSet[i] })
+// drawFromDeck takes up to n cards from the top of the deck
+func (t *Table) drawFromDeck(n int) []Quarter {
+	if n > len(t.deck) {
+		n = len(t.deck)
+	}
+	cards := make([]Quarter, n)
+	copy(cards, t.deck[:n])
+	t.deck = t.deck[n:]
+	return cards
+}
 
-	t.openLockedHeroes = make([]Hero, 0)
-	t.closedLockedHeroes = make([]Hero, 0)
-	var finalIndex int
+// swapHands exchanges all Player.AvailableQuarters of two players
+func (t *Table) swapHands(caster *Player, targetID PlayerID) error {
+	target, ok := t.playerByID(string(targetID))
+	if !ok {
+		return ErrPlayerNotExists
+	}
+	if target.ID == caster.ID {
+		return ErrCannotCastOnMyself
+	}
 
-	for i, hero := range heroSet {
-		t.openLockedHeroes = append(t.openLockedHeroes, hero)
-		if len(t.players) == 4 && i+1 == 3 {
-			t.closedLockedHeroes = append(t.closedLockedHeroes, heroSet[0])
-			finalIndex = i
-			break
+	caster.AvailableQuarters, target.AvailableQuarters = target.AvailableQuarters, caster.AvailableQuarters
+
+	for _, p := range []*Player{caster, target} {
+		p.Notify(Event{
+			Type: EventTypeHandsSwappedPrivate,
+			Data: EventHandsSwappedCards{
+				CasterID:          caster.ID,
+				TargetID:          target.ID,
+				AvailableQuarters: p.AvailableQuarters,
+			},
+		})
+	}
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeHandsSwapped,
+		Data: EventHandsSwapped{
+			CasterID:    caster.ID,
+			TargetID:    target.ID,
+			CasterCards: len(caster.AvailableQuarters),
+			TargetCards: len(target.AvailableQuarters),
+		},
+	})
+	return nil
+}
+
+// exchangeCards puts cards with given names to the bottom of the deck
+// and gives Player the same number of cards from the top
+func (t *Table) exchangeCards(p *Player, cardNames []string) error {
+	hand := make([]Quarter, len(p.AvailableQuarters))
+	copy(hand, p.AvailableQuarters)
+
+	discarded := make([]Quarter, 0, len(cardNames))
+	for _, name := range cardNames {
+		var found bool
+		for i, card := range hand {
+			if card.Name == name {
+				discarded = append(discarded, card)
+				hand = removeQuarter(hand, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return ErrCardNotInHand
 		}
 	}
-	t.heroesToSelect = heroSet[finalIndex:]
+
+	t.deck = append(t.deck, discarded...)
+	p.AvailableQuarters = append(hand, t.drawFromDeck(len(discarded))...)
+
+	p.Notify(Event{
+		Type: EventTypeCardsExchangedPrivate,
+		Data: EventCards{Cards: p.AvailableQuarters},
+	})
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeCardsExchanged,
+		Data: EventCardsExchanged{
+			PlayerID: p.ID,
+			Count:    len(discarded),
+		},
+	})
+	return nil
+}
+
+func (t *Table) startPickPhase() {
+	t.currentPhase = PickPhase
+
+	heroSet := make([]Hero, len(t.heroes))
+	copy(heroSet, t.heroes)
+
+	t.rng.Shuffle(len(heroSet), func(i, j int) { heroSet[i], heroSet[j] = heroSet[j], heroSet[i] })
+
+	t.heroesToSelect = t.removeHeroes(heroSet)
+	for _, p := range t.players {
+		p.Heroes = make([]Hero, 0, heroesPerPlayer(len(t.players)))
+	}
+	t.picks = t.pickOrder()
+	t.pick = 0
+	t.discardedHeroes = make([]Hero, 0)
+	t.discarding = false
 
 	t.doBroadcastEvent(Event{
 		Type: EventTypePickPhaseStarted,
@@ -212,8 +422,8 @@ func (t *Table) startPickPhase() {
 		},
 	})
 
-	t.selecting = t.king
-	t.king.Notify(Event{
+	t.selecting = t.picks[0]
+	t.selecting.Notify(Event{
 		Type: EventTypeChooseHero,
 		Data: EventChooseHero{Heroes: t.heroesToSelect},
 	})
@@ -221,41 +431,132 @@ func (t *Table) startPickPhase() {
 	t.startSelectingTimer()
 }
 
-func (t *Table) nextSelecting() {
-	playersCount := len(t.players)
-	currentPlayerOrder := t.selecting.Order
+// afterPick asks selecting player to put a hero aside if the rules want it,
+// otherwise the next player selects
+func (t *Table) afterPick() {
+	if !removals[len(t.players)].PickerDiscards || t.pick == 0 || len(t.heroesToSelect) == 0 {
+		t.nextSelecting()
+		return
+	}
 
-	nextPlayerOrder := currentPlayerOrder + 1
-	if nextPlayerOrder > playersCount {
-		nextPlayerOrder = 1
+	// the last hero is put aside without a choice
+	if len(t.heroesToSelect) == 1 {
+		t.putAside(0)
+		return
 	}
 
-	for _, p := range t.players {
-		if p.Order == nextPlayerOrder {
+	t.discarding = true
+	t.selecting.Notify(Event{
+		Type: EventTypeChooseDiscard,
+		Data: EventChooseHero{Heroes: t.heroesToSelect},
+	})
+	t.startSelectingTimer()
+}
 
-			// if turn returns to the king this is means that all players at the table selected their heroes
-			if t.king.ID == p.ID {
-				t.startActionPhase()
-				return
-			}
-			t.selecting = p
+// DiscardHero puts the hero from the stack aside face down,
+// the player discards after his pick in the game of 2 players
+func (t *Table) DiscardHero(pID string, heroName string) error {
+	t.Lock()
+	defer t.Unlock()
+	return t.record(Command{Type: CommandTypeDiscardHero, PlayerID: PlayerID(pID), Hero: heroName}, func() error {
+		return t.discardHero(PlayerID(pID), heroName)
+	})
+}
 
-			p.Notify(Event{
-				Type: EventTypeChooseHero,
-				Data: EventChooseHero{Heroes: t.heroesToSelect},
-			})
+// discardHero returns why the hero can not be put aside
+func (t *Table) discardHero(pID PlayerID, heroName string) error {
+	if t.currentPhase != PickPhase {
+		return ErrWrongPhase
+	}
+	if t.selecting.ID != pID {
+		return ErrAnotherPlayerSelecting
+	}
+	if !t.discarding {
+		return ErrNoDiscard
+	}
 
-			t.doBroadcastEvent(Event{
-				Type: EventTypeNextSelecting,
-				Data: EventPlayerID{PlayerID: p.ID},
-			})
-			break
+	for i, hero := range t.heroesToSelect {
+		if hero.Name == heroName {
+			t.putAside(i)
+			return nil
 		}
 	}
+	return ErrHeroNotInStack
+}
+
+// putAside discards the hero with index i from the stack and passes the stack on
+func (t *Table) putAside(i int) {
+	hero := t.heroesToSelect[i]
+	t.heroesToSelect = removeHero(t.heroesToSelect, i)
+	t.discardedHeroes = append(t.discardedHeroes, hero)
+	t.discarding = false
+
+	t.selecting.Notify(Event{
+		Type: EventTypeHeroDiscarded,
+		Data: EventHeroSelected{Hero: hero},
+	})
+	t.doBroadcastEvent(Event{
+		Type: EventTypePlayerDiscardedHero,
+		Data: EventPlayerID{PlayerID: t.selecting.ID},
+	})
+	t.nextSelecting()
+}
+
+func (t *Table) nextSelecting() {
+	t.pick++
+
+	// all players at the table selected their heroes
+	if t.pick >= len(t.picks) {
+		t.startActionPhase()
+		return
+	}
+	p := t.picks[t.pick]
+	t.selecting = p
+
+	if t.lastSelecting() && removals[len(t.players)].LastTakesClosed {
+		t.heroesToSelect = append(t.heroesToSelect, t.closedLockedHeroes...)
+		t.closedLockedHeroes = make([]Hero, 0)
+	}
+
+	p.Notify(Event{
+		Type: EventTypeChooseHero,
+		Data: EventChooseHero{Heroes: t.heroesToSelect},
+	})
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeNextSelecting,
+		Data: EventPlayerID{PlayerID: p.ID},
+	})
 
 	t.startSelectingTimer()
 }
 
+// moveCrown makes p a king, the next round starts from him
+func (t *Table) moveCrown(p *Player) {
+	from := t.king
+	t.king = p
+	t.crownMoved = true
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeCrownMoved,
+		Data: EventCrownMoved{
+			From: from.ID,
+			To:   p.ID,
+		},
+	})
+}
+
+// passCrown gives the crown to the first player clockwise from p
+// who is neither p nor the current king
+func (t *Table) passCrown(p *Player) {
+	for next := t.playerAfter(p); next.ID != p.ID; next = t.playerAfter(next) {
+		if next.ID != t.king.ID {
+			t.moveCrown(next)
+			return
+		}
+	}
+}
+
 func (t *Table) startActionPhase() {
 	t.currentPhase = ActionPhase
 
@@ -270,33 +571,66 @@ func (t *Table) nextTurn() {
 	t.currentIndex += 1
 	if t.currentIndex > 9 {
 		t.currentIndex = 1
+		t.roundEndHooks()
 		t.endRound()
 		if t.currentPhase != EndGamePhase {
+			t.resetRound()
 			t.startPickPhase()
 		}
 		return
 	}
 
-	for _, p := range t.players {
-		if p.Hero.Turn == t.currentIndex {
-			t.turn = p
+	if p, hero, ok := t.turnHolder(t.currentIndex); ok {
+		if t.killedTurn == t.currentIndex {
+			// killed hero silently misses the turn
+			t.doBroadcastEvent(Event{
+				Type: EventTypeKilledHeroSkipped,
+				Data: EventHeroIsAbsent{
+					Turn:     t.currentIndex,
+					HeroName: hero.Name,
+				},
+			})
+			t.Sleep(DelayAfterHeroAbsent)
+			t.nextTurn()
+			return
+		}
+
+		t.turn = p
+		t.turnHero = hero
 
-			t.turn.madeAction = false
-			t.turn.currentCardsChoice = nil
-			t.turn.BuildChancesLeft = 1
+		t.turn.resetTurnState()
+		t.turn.BuildChancesLeft = t.buildLimit(hero)
 
+		t.doBroadcastEvent(Event{
+			Type: EventTypeNextTurn,
+			Data: EventNextTurn{
+				PlayerID: p.ID,
+				Hero:     hero,
+				Turn:     hero.Turn,
+			},
+		})
+
+		if t.robbedTurn == t.currentIndex && t.thief != nil && t.thief.ID != p.ID {
+			t.rob(p)
+		}
+
+		if t.bewitchedTurn == t.currentIndex && t.witch != nil && t.witch.ID != p.ID {
+			// bewitched player only collects resources, the rest of the turn belongs to the Witch
+			t.bewitchedPlayer = p
+			p.BuildChancesLeft = 0
 			t.doBroadcastEvent(Event{
-				Type: EventTypeNextTurn,
-				Data: EventNextTurn{
+				Type: EventTypePlayerBewitched,
+				Data: EventPlayerBewitched{
 					PlayerID: p.ID,
-					Hero:     p.Hero,
-					Turn:     p.Hero.Turn,
+					WitchID:  t.witch.ID,
 				},
 			})
-
-			t.startTurnTimer()
-			return
+		} else {
+			t.startHeroTurn(p, hero)
 		}
+
+		t.startTurnTimer()
+		return
 	}
 
 	t.doBroadcastEvent(Event{
@@ -314,79 +648,254 @@ func (t *Table) nextTurn() {
 func (t *Table) EndTurn(pID PlayerID) {
 	t.Lock()
 	defer t.Unlock()
+	t.record(Command{Type: CommandTypeEndTurn, PlayerID: pID}, func() error {
+		return t.finishTurn(pID)
+	})
+}
+
+// finishTurn ends the turn of player, returns why the turn can not be ended
+func (t *Table) finishTurn(pID PlayerID) error {
+	p, ok := t.players[pID]
+	if !ok {
+		return ErrPlayerNotExists
+	}
 
-	if t.turn.ID == pID {
-		t.nextTurn()
+	err := t.checkEndTurn(p)
+	if err == ErrThreatUnresolved {
+		t.promptThreat(p)
 	}
+	if err != nil {
+		return err
+	}
+
+	t.endTurn()
+	return nil
 }
 
-func (t *Table) endRound() {
-	for _, p := range t.players {
-		if len(p.CompletedQuarters) == 7 && t.currentPhase != EndGamePhase {
-			t.currentPhase = EndGamePhase
-		}
+// checkEndTurn returns why player can not end the turn
+func (t *Table) checkEndTurn(p *Player) error {
+	if t.currentPhase != ActionPhase {
+		return ErrWrongPhase
 	}
 
-	if t.currentPhase != EndGamePhase {
+	if t.turn.ID != p.ID {
+		return ErrNotYourTurn
+	}
+
+	// threatened player has to answer the Blackmailer before leaving
+	if t.threatUnresolved() || t.threatPending(p) {
+		return ErrThreatUnresolved
+	}
+
+	// the Warlord waits for the owner of the Graveyard
+	if t.salvageOffer != nil {
+		return ErrSalvageUnresolved
+	}
+	return nil
+}
+
+// endTurn finishes current turn, bewitched player hands the turn over to the Witch
+func (t *Table) endTurn() {
+	t.expireThreat()
+	t.declineSalvage()
+	if t.isBewitchedTurn() {
+		t.passToWitch()
 		return
 	}
-	t.completedQuartersFirst.totalScore += 4
-	var winner *Player
-	for _, p := range t.players {
-		for _, quarter := range p.CompletedQuarters {
-			p.totalScore += quarter.Price
+	if t.turnHero.Skill.OnTurnEnd != nil {
+		t.turnHero.Skill.OnTurnEnd(t, t.turn)
+	}
+	quarterHooks(t.turn, func(q Quarter, ability QuarterAbility) {
+		if ability.OnTurnEnd != nil {
+			ability.OnTurnEnd(t, t.turn)
 		}
-		if len(p.CompletedQuarters) == 7 && p.ID != t.completedQuartersFirst.ID {
-			p.totalScore += 2
+	})
+	t.nextTurn()
+}
+
+// roundEndHooks calls OnRoundEnd of every hero in the set
+func (t *Table) roundEndHooks() {
+	for _, hero := range t.heroes {
+		if hero.Skill.OnRoundEnd == nil {
+			continue
 		}
-		if winner == nil {
-			winner = p
+		hero.Skill.OnRoundEnd(t, t.heroHolder(hero))
+	}
+}
+
+// heroHolder returns player who plays the hero this round, nil if nobody does
+func (t *Table) heroHolder(hero Hero) *Player {
+	p, _, _ := t.turnHolder(hero.Turn)
+	return p
+}
+
+// turnHolder returns player who plays the hero of the turn this round and the hero,
+// seats are looked through clockwise
+func (t *Table) turnHolder(turn int) (*Player, Hero, bool) {
+	for _, p := range t.seats {
+		if hero, ok := p.heroByTurn(turn); ok {
+			return p, hero, true
 		}
-		if p.totalScore > winner.totalScore {
-			winner = p
+	}
+	return nil, Hero{}, false
+}
+
+// isBewitchedTurn reports whether bewitched player is taking his part of the turn right now
+func (t *Table) isBewitchedTurn() bool {
+	return t.bewitchedPlayer != nil &&
+		t.currentIndex == t.bewitchedTurn &&
+		t.turn.ID == t.bewitchedPlayer.ID
+}
+
+// passToWitch gives the rest of bewitched hero's turn to the Witch
+// the Witch can not collect resources but builds and uses the skill of bewitched hero
+func (t *Table) passToWitch() {
+	victim := t.bewitchedPlayer
+	hero, _ := victim.heroByTurn(t.bewitchedTurn)
+	t.turn = t.witch
+	t.turnHero = hero
+
+	t.witch.resetTurnState()
+	t.witch.madeAction = true
+	t.witch.BuildChancesLeft = t.buildLimit(hero)
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeWitchTakesTurn,
+		Data: EventWitchTakesTurn{
+			WitchID:  t.witch.ID,
+			PlayerID: victim.ID,
+			Hero:     hero,
+		},
+	})
+
+	t.startHeroTurn(t.witch, hero)
+
+	t.startTurnTimer()
+}
+
+// startHeroTurn gives Player everything the hero brings at the start of the turn
+func (t *Table) startHeroTurn(p *Player, hero Hero) {
+	t.drawBonusCards(p, hero)
+	if hero.Skill.OnTurnStart != nil {
+		hero.Skill.OnTurnStart(t, p)
+	}
+}
+
+// buildLimit returns how many quarters can be built per turn with the hero
+func (t *Table) buildLimit(hero Hero) int {
+	if hero.BuildLimit > 0 {
+		return hero.BuildLimit
+	}
+	return t.rules.BuildLimit
+}
+
+// drawBonusCards gives Player extra cards of the hero at the start of the turn
+func (t *Table) drawBonusCards(p *Player, hero Hero) {
+	if hero.BonusCards == 0 {
+		return
+	}
+
+	t.giveCards(p, t.drawFromDeck(hero.BonusCards))
+}
+
+// afterResources is called when Player received resources of his turn
+func (t *Table) afterResources(p *Player) {
+	if t.turn.ID != p.ID {
+		return
+	}
+	if t.threatUnresolved() || t.promptThreat(p) {
+		return
+	}
+	if t.isBewitchedTurn() {
+		t.passToWitch()
+	}
+}
+
+// resetRound clears state that lives only one round
+func (t *Table) resetRound() {
+	t.crownMoved = false
+	t.witch = nil
+	t.bewitchedTurn = 0
+	t.bewitchedPlayer = nil
+	t.blackmailer = nil
+	t.threats = nil
+	t.customsPool = 0
+	t.customsOfficer = nil
+	t.killedTurn = 0
+	t.thief = nil
+	t.robbedTurn = 0
+}
+
+func (t *Table) endRound() {
+	for _, p := range t.players {
+		if len(p.CompletedQuarters) >= t.rules.CitySize && t.currentPhase != EndGamePhase {
+			t.currentPhase = EndGamePhase
 		}
 	}
 
-	if winner == nil {
+	if t.currentPhase != EndGamePhase {
+		return
+	}
+	scores := t.scores()
+	if len(scores) == 0 {
 		return
 	}
+	for _, s := range scores {
+		t.players[s.PlayerID].totalScore = s.Total
+	}
 
 	t.doBroadcastEvent(Event{Type: EventTypeGameEnded,
-		Data: EventGameEnded{Winner: winner.ID},
+		Data: EventGameEnded{Winner: scores[0].PlayerID, Scores: scores},
 	})
 
 	t.close()
-	t.done <- struct{}{}
+	close(t.done)
 }
 
 func (t *Table) startTurnTimer() {
+	if t.noTimers {
+		return
+	}
 	playerID := t.turn.ID
-	time.AfterFunc(time.Minute, func() {
+	time.AfterFunc(t.rules.TurnTimeout, func() {
 		t.Lock()
 		defer t.Unlock()
-		if t.currentPhase != ActionPhase {
-			return
-		}
-		if t.turn.ID == playerID {
-			t.nextTurn()
-		}
+		t.timeout(playerID, ActionPhase)
 	})
 }
 
 func (t *Table) startSelectingTimer() {
+	if t.noTimers {
+		return
+	}
 	playerID := t.selecting.ID
-	time.AfterFunc(time.Minute, func() {
+	time.AfterFunc(t.rules.SelectTimeout, func() {
 		t.Lock()
 		defer t.Unlock()
-		if t.currentPhase != ActionPhase {
-			return
-		}
-		if t.selecting.ID == playerID {
-			t.forceSelecting()
-		}
+		t.timeout(playerID, PickPhase)
 	})
 }
 
+// timeout ends the turn or selects a hero for inactive player if the phase is still the same
+func (t *Table) timeout(pID PlayerID, phase Phase) {
+	if t.currentPhase != phase {
+		return
+	}
+
+	switch {
+	case phase == ActionPhase && t.turn.ID == pID:
+		t.record(Command{Type: CommandTypeTimeout, PlayerID: pID}, func() error {
+			t.endTurn()
+			return nil
+		})
+	case phase == PickPhase && t.selecting.ID == pID:
+		t.record(Command{Type: CommandTypeTimeout, PlayerID: pID}, func() error {
+			t.forceSelecting()
+			return nil
+		})
+	}
+}
+
 // Started returns current state of the table
 func (t *Table) Started() bool {
 	t.Lock()
@@ -408,57 +917,125 @@ func (t *Table) Turn() *Player {
 	return t.turn
 }
 
-// SelectHero sets Player.Hero to Hero with given heroName if
+// SelectHero adds Hero to Player.Heroes with given heroName if
 // Player is currently selecting and Hero with heroName is present in heroesToSelect
 func (t *Table) SelectHero(p *Player, heroName string) {
 	t.Lock()
 	defer t.Unlock()
+	t.record(Command{Type: CommandTypeSelectHero, PlayerID: p.ID, Hero: heroName}, func() error {
+		return t.selectHero(p, heroName)
+	})
+}
 
+// selectHero returns why the hero can not be selected
+func (t *Table) selectHero(p *Player, heroName string) error {
 	if t.currentPhase != PickPhase {
-		return
+		return ErrWrongPhase
 	}
 
 	if t.selecting.ID != p.ID {
 		p.Notify(Event{Error: ErrorTypeAnotherPlayerSelecting})
-		return
+		return ErrAnotherPlayerSelecting
+	}
+
+	if t.discarding {
+		return ErrDiscardPending
 	}
 
 	for i, hero := range t.heroesToSelect {
 		if hero.Name == heroName {
-			t.selecting.Hero = t.heroesToSelect[i]
+			t.selecting.Heroes = append(t.selecting.Heroes, hero)
 			t.heroesToSelect = removeHero(t.heroesToSelect, i)
 			p.Notify(Event{
 				Type: EventTypeHeroSelected,
 				Data: EventHeroSelected{Hero: hero},
 			})
-			t.nextSelecting()
-			return
+			t.afterPick()
+			return nil
 		}
 	}
 	p.Notify(Event{
 		Data:  EventChooseHero{Heroes: t.heroesToSelect},
 		Error: ErrorTypeHeroNotInStack,
 	})
+	return ErrHeroNotInStack
 }
 
+// CastSkill uses skill of the hero who takes the turn, the turn goes on after the cast
+// unless the skill ends it by itself as the Witch does
 func (t *Table) CastSkill(casterID string, ev Event) error {
 	t.Lock()
 	defer t.Unlock()
+	return t.record(Command{Type: CommandTypeCastSkill, PlayerID: PlayerID(casterID), Data: commandData(ev)}, func() error {
+		return t.castSkill(PlayerID(casterID), ev)
+	})
+}
 
-	caster, ok := t.PlayerByID(casterID)
+// castSkill casts skill of the hero who takes the turn
+func (t *Table) castSkill(casterID PlayerID, ev Event) error {
+	caster, ok := t.players[casterID]
 	if !ok {
 		return ErrPlayerNotExists
 	}
 
-	err := caster.Hero.Skill.Do(t, caster, ev)
+	if err := t.checkCast(caster); err != nil {
+		return err
+	}
+
+	// decoded CastSkill event carries data of the skill in the envelope,
+	// data decoded for another hero does not fit the skill
+	if e, ok := ev.Data.(EventCastSkill); ok {
+		if e.Hero != t.turnHero.Name {
+			return ErrWrongEventData
+		}
+		ev.Data = e.Data
+	}
+
+	// skill may end the turn by itself, so it is marked as used beforehand
+	caster.skillUsed = true
+	err := t.turnHero.Skill.Do(t, caster, ev)
 	if err != nil {
+		caster.skillUsed = false
 		return err
 	}
 
-	t.nextTurn()
 	return nil
 }
 
+// checkCast returns why caster can not use the skill whatever data he sends
+func (t *Table) checkCast(caster *Player) error {
+	if t.currentPhase != ActionPhase || t.turn.ID != caster.ID {
+		return ErrNotYourTurn
+	}
+
+	if t.isBewitchedTurn() {
+		return ErrPlayerBewitched
+	}
+
+	if t.threatUnresolved() {
+		return ErrThreatUnresolved
+	}
+
+	if caster.skillUsed {
+		return ErrSkillAlreadyUsed
+	}
+
+	if t.turnHero.Skill.Do == nil {
+		return ErrNoActiveSkill
+	}
+	return nil
+}
+
+// heroByName returns hero with given name from the hero set of the table
+func (t *Table) heroByName(name string) (Hero, bool) {
+	for _, hero := range t.heroes {
+		if hero.Name == name {
+			return hero, true
+		}
+	}
+	return Hero{}, false
+}
+
 const (
 	ActionTypeCoin  = "coins"
 	ActionTypeCards = "cards"
@@ -468,138 +1045,289 @@ const (
 func (t *Table) MakeAction(actionType string, pID string) {
 	t.Lock()
 	defer t.Unlock()
+	t.record(Command{Type: CommandTypeMakeAction, PlayerID: PlayerID(pID), Action: actionType}, func() error {
+		return t.makeAction(actionType, PlayerID(pID))
+	})
+}
 
-	target, ok := t.playerByID(pID)
+// makeAction returns why player can not take the resources
+func (t *Table) makeAction(actionType string, pID PlayerID) error {
+	target, ok := t.players[pID]
 	if !ok {
-		return
+		return ErrPlayerNotExists
 	}
 
-	if t.turn.ID != target.ID {
-		return
+	err := t.checkAction(target, actionType)
+	switch err {
+	case nil:
+	case ErrDeckIsEmpty:
+		target.Notify(Event{
+			Error: ErrorTypeDeckIsEmpty,
+		})
+		return err
+	case ErrWrongAction:
+		target.Notify(Event{
+			Error: ErrorTypeWrongAction,
+		})
+		return err
+	default:
+		return err
 	}
 
-	if target.madeAction {
-		return
-	}
+	income := &Income{Coins: t.rules.CoinIncome, Draw: t.rules.CardsDrawn, Keep: t.rules.CardsKept}
+	quarterHooks(target, func(q Quarter, ability QuarterAbility) {
+		if ability.OnIncome != nil {
+			ability.OnIncome(t, target, income)
+		}
+	})
 
 	switch actionType {
 	case ActionTypeCoin:
-		target.AddCoins(2)
+		target.AddCoins(income.Coins)
 		t.doBroadcastEvent(Event{Type: EventTypeCoinsGive, Data: EventCoinGive{
 			To:     target.ID,
-			Amount: 2,
+			Amount: income.Coins,
 			Sum:    target.Coins,
 		}})
 
 	case ActionTypeCards:
-		target.setCurrentCardsChoice(t.deck[:2])
+		cards := t.drawFromDeck(income.Draw)
+		if income.KeepAll || income.Keep >= len(cards) {
+			t.giveCards(target, cards)
+			target.madeAction = true
+			t.afterResources(target)
+			return nil
+		}
+		target.setCurrentCardsChoice(cards)
+		target.cardsToKeep = income.Keep
 		target.Notify(Event{Type: EventTypeChooseCards, Data: EventChooseCards{
-			Cards: t.deck[:2],
+			Cards: cards,
 		}})
-		t.deck = t.deck[2:]
 		t.doBroadcastEvent(Event{Type: EventTypePlayerChoosingCards, Data: EventPlayerChoosingCards{
 			PlayerID:    target.ID,
-			CardsAmount: 2,
+			CardsAmount: len(cards),
 		}})
-	default:
-		target.Notify(Event{
-			Error: ErrorTypeWrongAction,
-		})
-		return
 	}
 
 	target.madeAction = true
+
+	if actionType == ActionTypeCoin {
+		t.afterResources(target)
+	}
+	return nil
+}
+
+// checkAction returns why player can not take the resources of the type
+func (t *Table) checkAction(p *Player, actionType string) error {
+	if t.currentPhase != ActionPhase {
+		return ErrWrongPhase
+	}
+
+	if t.turn.ID != p.ID {
+		return ErrNotYourTurn
+	}
+
+	if p.madeAction {
+		return ErrActionAlreadyMade
+	}
+
+	switch actionType {
+	case ActionTypeCoin:
+	case ActionTypeCards:
+		if len(t.deck) == 0 {
+			return ErrDeckIsEmpty
+		}
+	default:
+		return ErrWrongAction
+	}
+	return nil
 }
 
 // SelectCard adds card to Player.AvailableQuarters
 func (t *Table) SelectCard(cardName string, pID string) {
 	t.Lock()
 	defer t.Unlock()
-	target, ok := t.playerByID(pID)
+	t.record(Command{Type: CommandTypeSelectCard, PlayerID: PlayerID(pID), Quarter: cardName}, func() error {
+		return t.selectCard(cardName, PlayerID(pID))
+	})
+}
+
+// selectCard returns why player can not keep the card
+func (t *Table) selectCard(cardName string, pID PlayerID) error {
+	target, ok := t.players[pID]
 	if !ok {
-		return
+		return ErrPlayerNotExists
 	}
 
-	if target.currentCardsChoice == nil || len(target.currentCardsChoice) < 2 {
-		return
+	if err := t.checkSelectCard(target, cardName); err != nil {
+		return err
 	}
 
 	for i, card := range target.currentCardsChoice {
 		if card.Name == cardName {
 			target.AddQuarter(card)
-			target.currentCardsChoice = nil
+			target.currentCardsChoice = removeQuarter(target.currentCardsChoice, i)
+			target.cardsToKeep--
 
 			t.doBroadcastEvent(Event{Type: EventTypePlayerSelectedCard, Data: EventPlayerSelectedCard{
 				PlayerID: target.ID,
 				Index:    i,
 			}})
-			break
+
+			if target.cardsToKeep > 0 && len(target.currentCardsChoice) > 0 {
+				target.Notify(Event{Type: EventTypeChooseCards, Data: EventChooseCards{
+					Cards: target.currentCardsChoice,
+				}})
+				return nil
+			}
+
+			// cards that were not chosen go to the bottom of the deck
+			t.deck = append(t.deck, target.currentCardsChoice...)
+			target.currentCardsChoice = nil
+			t.afterResources(target)
+			return nil
 		}
 	}
-
+	return ErrCardNotInChoice
 }
 
-func (t *Table) BuildQuarter(quarter Quarter, pID string) {
-	t.Lock()
-	defer t.Unlock()
-
-	target, ok := t.playerByID(pID)
-	if !ok {
-		return
+// checkSelectCard returns why player can not keep the card
+func (t *Table) checkSelectCard(p *Player, cardName string) error {
+	// choice left at the end of the turn is not taken any more
+	if t.currentPhase != ActionPhase || t.turn.ID != p.ID {
+		return ErrNotYourTurn
 	}
 
-	if target.BuildChancesLeft < 1 {
-		return
+	if len(p.currentCardsChoice) == 0 {
+		return ErrNoCardsChoice
 	}
 
-	if t.currentPhase != ActionPhase {
-		return
+	for _, card := range p.currentCardsChoice {
+		if card.Name == cardName {
+			return nil
+		}
 	}
+	return ErrCardNotInChoice
+}
 
-	if t.turn.ID != target.ID {
-		return
+func (t *Table) BuildQuarter(quarter Quarter, pID string) {
+	t.Lock()
+	defer t.Unlock()
+	t.record(Command{Type: CommandTypeBuild, PlayerID: PlayerID(pID), Quarter: quarter.Name}, func() error {
+		return t.build(quarter.Name, PlayerID(pID))
+	})
+}
+
+// build returns why player can not build the quarter
+func (t *Table) build(quarterName string, pID PlayerID) error {
+	target, ok := t.players[pID]
+	if !ok {
+		return ErrPlayerNotExists
 	}
 
-	if quarter.Price > target.Coins {
+	build, err := t.checkBuild(target, quarterName)
+	switch err {
+	case nil:
+	case ErrSkillNotCast:
+		target.Notify(Event{
+			Error: ErrorTypeSkillNotCast,
+		})
+		return err
+	case ErrNotEnoughCoins:
 		target.Notify(Event{
 			Error: ErrorTypeNotEnoughCoins,
 		})
-		return
-	}
-
-	if !target.hasQuarter(quarter.Name) {
-		return
-	}
-
-	if target.builtQuarter(quarter.Name) {
+		return err
+	case ErrQuarterAlreadyBuilt:
 		target.Notify(Event{
 			Error: ErrorTypeQuarterAlreadyBuilt,
 		})
-		return
+		return err
+	default:
+		return err
 	}
 
+	quarter, cost := build.Quarter, build.Cost
 	target.buildQuarter(quarter)
+	target.AddCoins(-cost)
+	target.spentThisTurn += cost
 	t.doBroadcastEvent(Event{Type: EventTypePlayerBuiltQuarter,
 		Data: EventQuarter{Quarter: quarter},
 	})
 	target.SubtractBuildChancesLeft(1)
+	t.afterBuild(build)
+	if ability, ok := abilityOf(quarter); ok && ability.OnBuild != nil {
+		ability.OnBuild(t, target, quarter)
+	}
 
-	if len(target.CompletedQuarters) == 7 {
+	if len(target.CompletedQuarters) >= t.rules.CitySize && t.completedQuartersFirst == nil {
 		t.completedQuartersFirst = target
 	}
+	return nil
+}
+
+// checkBuild returns the build of the quarter from the hand or why player can not build it
+func (t *Table) checkBuild(p *Player, quarterName string) (*Build, error) {
+	if p.BuildChancesLeft < 1 {
+		return nil, ErrNoBuildChances
+	}
+
+	if t.currentPhase != ActionPhase {
+		return nil, ErrWrongPhase
+	}
+
+	if t.turn.ID != p.ID {
+		return nil, ErrNotYourTurn
+	}
+
+	if t.threatUnresolved() {
+		return nil, ErrThreatUnresolved
+	}
+
+	// skill of this type has to be cast before building
+	if t.turnHero.Skill.Type == SkillTypeAtStart && !p.skillUsed {
+		return nil, ErrSkillNotCast
+	}
+
+	// price is taken from the card in hand, not from the request
+	quarter, ok := p.quarterInHand(quarterName)
+	if !ok {
+		return nil, ErrCardNotInHand
+	}
+
+	build := &Build{Player: p, Quarter: quarter, Cost: quarter.Price}
+	t.beforeBuild(build)
+
+	if build.Cost > p.Coins {
+		return nil, ErrNotEnoughCoins
+	}
+
+	if p.builtQuarter(quarter.Name) {
+		return nil, ErrQuarterAlreadyBuilt
+	}
+	return build, nil
 }
 
 // AddPlayer adds player to the table
 func (t *Table) AddPlayer(p *Player) error {
 	t.Lock()
 	defer t.Unlock()
+	return t.record(Command{Type: CommandTypeJoin, PlayerID: p.ID}, func() error {
+		return t.addPlayer(p)
+	})
+}
+
+// addPlayer seats player after the others
+func (t *Table) addPlayer(p *Player) error {
 	if t.started {
 		return ErrTableAlreadyStarted
 	}
-	if len(t.players) == MaxPlayers {
+	if len(t.players) >= t.maxPlayers() {
 		return ErrTableIsFull
 	}
 	t.players[p.ID] = p
+	t.seats = append(t.seats, p)
+	t.reseat()
 	p.Table = t
 	return nil
 }
@@ -608,11 +1336,23 @@ func (t *Table) AddPlayer(p *Player) error {
 func (t *Table) RemovePlayer(pID PlayerID) error {
 	t.Lock()
 	defer t.Unlock()
+	return t.record(Command{Type: CommandTypeLeave, PlayerID: pID}, func() error {
+		return t.removePlayer(pID)
+	})
+}
+
+// removePlayer frees the seat of player
+func (t *Table) removePlayer(pID PlayerID) error {
 	if t.started {
 		return ErrTableAlreadyStarted
 	}
-	p := t.players[pID]
+	p, ok := t.players[pID]
+	if !ok {
+		return ErrPlayerNotExists
+	}
 	delete(t.players, pID)
+	t.seats = removePlayer(t.seats, p.Order-1)
+	t.reseat()
 	p.Table = nil
 	return nil
 }
@@ -1,6 +1,9 @@
 package citadels
 
-import "math/rand"
+import (
+	"encoding/json"
+	"reflect"
+)
 
 // BroadcastEvent sends Event to all players at the table
 func (t *Table) BroadcastEvent(e Event) {
@@ -10,6 +13,7 @@ func (t *Table) BroadcastEvent(e Event) {
 }
 
 func (t *Table) doBroadcastEvent(e Event) {
+	t.recordEvent("", e)
 	for _, p := range t.players {
 		p.updates <- e
 	}
@@ -29,15 +33,19 @@ func (t *Table) playerByID(id string) (*Player, bool) {
 }
 
 func (t *Table) forceSelecting() {
-	randomIndex := rand.Intn(len(t.heroesToSelect))
+	randomIndex := t.rng.Intn(len(t.heroesToSelect))
+	if t.discarding {
+		t.putAside(randomIndex)
+		return
+	}
 	hero := t.heroesToSelect[randomIndex]
-	t.selecting.Hero = hero
+	t.selecting.Heroes = append(t.selecting.Heroes, hero)
 	t.selecting.Notify(Event{
 		Type:  EventTypeHeroSelected,
 		Data:  EventHeroSelected{Hero: hero},
 	})
 	t.heroesToSelect = removeHero(t.heroesToSelect, randomIndex)
-	t.nextSelecting()
+	t.afterPick()
 }
 
 func removeQuarter(slice []Quarter, s int) []Quarter {
@@ -56,3 +64,43 @@ func removeQuarterByName(slice []Quarter, name string) []Quarter {
 func removeHero(slice []Hero, s int) []Hero {
 	return append(slice[:s], slice[s+1:]...)
 }
+
+func removePlayer(slice []*Player, s int) []*Player {
+	return append(slice[:s], slice[s+1:]...)
+}
+
+// emptyEventData reports whether the event carries no data, raw json null is no data too
+func emptyEventData(ev Event) bool {
+	if ev.Data == nil {
+		return true
+	}
+	raw, ok := ev.Data.(json.RawMessage)
+	return ok && isNull(raw)
+}
+
+// decodeEventData puts Event.Data into v, data of the same type is copied as is
+// and raw json is unmarshalled, missing data and data of another type are wrong
+func decodeEventData(ev Event, v interface{}) error {
+	if ev.Data == nil {
+		return ErrWrongEventData
+	}
+
+	b, ok := ev.Data.(json.RawMessage)
+	if ok {
+		if json.Unmarshal(b, v) != nil {
+			return ErrWrongEventData
+		}
+		return nil
+	}
+
+	dst := reflect.ValueOf(v)
+	src := reflect.ValueOf(ev.Data)
+	if src.Kind() == reflect.Ptr && !src.IsNil() {
+		src = src.Elem()
+	}
+	if dst.Kind() != reflect.Ptr || src.Type() != dst.Elem().Type() {
+		return ErrWrongEventData
+	}
+	dst.Elem().Set(src)
+	return nil
+}
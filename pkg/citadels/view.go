@@ -0,0 +1,158 @@
+package citadels
+
+// View is state of the table which is safe to send to a viewer,
+// it holds only what the viewer is allowed to know
+type View struct {
+	// Viewer is id of the player, empty for spectators
+	Viewer PlayerID `json:"viewer,omitempty"`
+
+	Phase        Phase    `json:"phase"`
+	CurrentIndex int      `json:"current_index"`
+	King         PlayerID `json:"king,omitempty"`
+	Turn         PlayerID `json:"turn,omitempty"`
+	Selecting    PlayerID `json:"selecting,omitempty"`
+	Rules        RuleSet  `json:"rules"`
+
+	// Players are listed by seats
+	Players []PlayerView `json:"players"`
+
+	OpenLockedHeroes   []Hero `json:"open_locked_heroes"`
+	ClosedLockedHeroes int    `json:"closed_locked_heroes"`
+	DiscardedHeroes    int    `json:"discarded_heroes"`
+
+	// SeenLockedHeroes are heroes put aside face down which the viewer has seen this round
+	SeenLockedHeroes []Hero `json:"seen_locked_heroes,omitempty"`
+
+	// DiscardSize is size of the discard pile, some quarters are discarded face down
+	DiscardSize int `json:"discard_size"`
+	DeckSize    int `json:"deck_size"`
+
+	// Hand, Heroes and CardsChoice belong to the viewer
+	Hand        []Quarter `json:"hand,omitempty"`
+	Heroes      []Hero    `json:"heroes,omitempty"`
+	CardsChoice []Quarter `json:"cards_choice,omitempty"`
+
+	// HeroesToSelect are shown only to the selecting viewer
+	HeroesToSelect []Hero `json:"heroes_to_select,omitempty"`
+}
+
+// PlayerView is public state of a player
+type PlayerView struct {
+	ID       PlayerID  `json:"id"`
+	Seat     int       `json:"seat"`
+	Coins    int       `json:"coins"`
+	HandSize int       `json:"hand_size"`
+	City     []Quarter `json:"city"`
+
+	// Heroes are heroes of the player which already took their turn this round
+	Heroes []Hero `json:"heroes"`
+
+	// Score is known at the end of the game
+	Score int `json:"score,omitempty"`
+}
+
+// ViewFor returns state of the table seen by the player
+func (t *Table) ViewFor(pID PlayerID) (View, error) {
+	t.Lock()
+	defer t.Unlock()
+
+	p, ok := t.players[pID]
+	if !ok {
+		return View{}, ErrPlayerNotExists
+	}
+
+	v := t.publicView()
+	v.Viewer = p.ID
+	p.Lock()
+	v.Hand = append(make([]Quarter, 0), p.AvailableQuarters...)
+	v.Heroes = append(make([]Hero, 0), p.Heroes...)
+	v.CardsChoice = append([]Quarter(nil), p.currentCardsChoice...)
+	p.Unlock()
+
+	if t.currentPhase == PickPhase && t.selecting != nil && t.selecting.ID == p.ID {
+		v.HeroesToSelect = append(make([]Hero, 0), t.heroesToSelect...)
+	}
+	v.SeenLockedHeroes = t.seenLockedHeroes(p)
+	return v, nil
+}
+
+// seenLockedHeroes returns heroes put aside face down which p has seen this round:
+// heroes discarded by p and the heroes which the last player leaves when he takes from the closed ones
+func (t *Table) seenLockedHeroes(p *Player) []Hero {
+	var heroes []Hero
+	for i, hero := range t.discardedHeroes {
+		if i+1 < len(t.picks) && t.picks[i+1].ID == p.ID {
+			heroes = append(heroes, hero)
+		}
+	}
+
+	last := len(t.picks) - 1
+	if t.currentPhase == ActionPhase && removals[len(t.players)].LastTakesClosed && last >= 0 && t.picks[last].ID == p.ID {
+		heroes = append(heroes, t.heroesToSelect...)
+	}
+	return heroes
+}
+
+// SpectatorView returns state of the table without any hidden information
+func (t *Table) SpectatorView() View {
+	t.Lock()
+	defer t.Unlock()
+	return t.publicView()
+}
+
+// publicView returns what everyone at the table knows
+func (t *Table) publicView() View {
+	v := View{
+		Phase:              t.currentPhase,
+		CurrentIndex:       t.currentIndex,
+		King:               playerID(t.king),
+		Rules:              t.rules,
+		Players:            make([]PlayerView, 0, len(t.seats)),
+		OpenLockedHeroes:   append(make([]Hero, 0), t.openLockedHeroes...),
+		ClosedLockedHeroes: len(t.closedLockedHeroes),
+		DiscardedHeroes:    len(t.discardedHeroes),
+		DiscardSize:        len(t.discard),
+		DeckSize:           len(t.deck),
+	}
+	switch t.currentPhase {
+	case PickPhase:
+		v.Selecting = playerID(t.selecting)
+	case ActionPhase:
+		v.Turn = playerID(t.turn)
+	}
+
+	for _, p := range t.seats {
+		p.Lock()
+		pv := PlayerView{
+			ID:       p.ID,
+			Seat:     p.Order,
+			Coins:    p.Coins,
+			HandSize: len(p.AvailableQuarters),
+			City:     append(make([]Quarter, 0), p.CompletedQuarters...),
+			Heroes:   make([]Hero, 0),
+		}
+		for _, hero := range p.Heroes {
+			if t.revealed(hero) {
+				pv.Heroes = append(pv.Heroes, hero)
+			}
+		}
+		if t.currentPhase == EndGamePhase {
+			pv.Score = p.totalScore
+		}
+		p.Unlock()
+		v.Players = append(v.Players, pv)
+	}
+	return v
+}
+
+// revealed reports whether everyone knows who plays the hero,
+// heroes are revealed when their turn comes, killed hero misses the turn and stays hidden
+func (t *Table) revealed(hero Hero) bool {
+	switch t.currentPhase {
+	case ActionPhase:
+		return hero.Turn <= t.currentIndex && hero.Turn != t.killedTurn
+	case EndGamePhase:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,141 @@
+package citadels
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// TestViewFor checks what players and spectators see
+func TestViewFor(t *testing.T) {
+	table, err := NewTable(TableOptions{HeroSet: HeroSetDefault, Seed: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range []PlayerID{"p1", "p2", "p3", "p4"} {
+		table.AddPlayer(NewPlayer(id, func(Event, *Player) {}))
+	}
+	table.Start()
+
+	table.Lock()
+	selecting := table.selecting
+	other := table.playerAfter(selecting)
+	table.Unlock()
+
+	v, err := table.ViewFor(selecting.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(v.Hand) != 4 || len(v.HeroesToSelect) == 0 {
+		t.Fatal("selecting player should see his hand and heroes to select")
+	}
+	for _, pv := range v.Players {
+		if pv.HandSize != 4 || pv.Coins != 2 {
+			t.Fatal("hand sizes and coins should be public")
+		}
+	}
+
+	v, _ = table.ViewFor(other.ID)
+	if len(v.HeroesToSelect) != 0 || v.Selecting != selecting.ID {
+		t.Fatal("heroes to select should be hidden from other players")
+	}
+	if len(v.OpenLockedHeroes) != 2 || v.ClosedLockedHeroes != 1 {
+		t.Fatal("only open locked heroes should be shown")
+	}
+
+	for i := 0; i < 4; i++ {
+		botStep(table)
+	}
+	table.Lock()
+	turn, index := table.turn, table.currentIndex
+	table.Unlock()
+
+	v, _ = table.ViewFor(turn.ID)
+	if len(v.Heroes) != 1 || v.Turn != turn.ID {
+		t.Fatal("player should see his hero")
+	}
+	for _, pv := range v.Players {
+		for _, hero := range pv.Heroes {
+			if hero.Turn > index {
+				t.Fatal("heroes should be hidden until their turn")
+			}
+		}
+	}
+
+	s := table.SpectatorView()
+	if s.Viewer != "" || len(s.Hand) != 0 || len(s.Heroes) != 0 {
+		t.Fatal("spectator should not see hidden information")
+	}
+	data, _ := json.Marshal(s)
+	if strings.Contains(string(data), `"hand"`) {
+		t.Fatal("spectator view should not carry a hand")
+	}
+
+	if _, err := table.ViewFor("unknown"); err != ErrPlayerNotExists {
+		t.Fatal("viewer should sit at the table, got ", err)
+	}
+}
+
+// TestViewHidden checks that the killed hero and face down cards stay hidden
+func TestViewHidden(t *testing.T) {
+	table, players := newTestTable(t, Assassin(), Thief(), Magician(), King())
+	thief := players[1]
+	table.currentPhase = ActionPhase
+	table.currentIndex = 3
+	table.killedTurn = 2
+	table.discard = []Quarter{{Name: "castle", Type: QuarterTypeNoble, Price: 4}}
+
+	s := table.SpectatorView()
+	for _, pv := range s.Players {
+		if pv.ID == thief.ID && len(pv.Heroes) != 0 {
+			t.Fatal("holder of the killed hero should stay hidden until the round ends")
+		}
+		if pv.ID == players[0].ID && len(pv.Heroes) != 1 {
+			t.Fatal("played hero should be revealed")
+		}
+	}
+	if s.DiscardSize != 1 {
+		t.Fatal("size of the discard pile should be public")
+	}
+	data, _ := json.Marshal(s)
+	if strings.Contains(string(data), "castle") {
+		t.Fatal("discarded quarters should not be shown")
+	}
+}
+
+// TestSeenLockedHeroes checks that players see the heroes which they put aside
+func TestSeenLockedHeroes(t *testing.T) {
+	table := startedTable(t, 7)
+	for table.currentPhase == PickPhase {
+		table.SelectHero(table.selecting, table.heroesToSelect[0].Name)
+	}
+	last := table.picks[len(table.picks)-1]
+	v, _ := table.ViewFor(last.ID)
+	if len(v.SeenLockedHeroes) == 0 || len(v.SeenLockedHeroes) != len(table.heroesToSelect) {
+		t.Fatal("the last player should see heroes which he left, got ", v.SeenLockedHeroes)
+	}
+	v, _ = table.ViewFor(table.picks[0].ID)
+	if len(v.SeenLockedHeroes) != 0 {
+		t.Fatal("closed heroes should be hidden from other players")
+	}
+
+	table = startedTable(t, 2)
+	for table.currentPhase == PickPhase {
+		if table.discarding {
+			table.DiscardHero(string(table.selecting.ID), table.heroesToSelect[0].Name)
+			continue
+		}
+		table.SelectHero(table.selecting, table.heroesToSelect[0].Name)
+	}
+	seen := 0
+	for _, id := range table.Seats() {
+		v, _ := table.ViewFor(id)
+		seen += len(v.SeenLockedHeroes)
+		if v.DiscardedHeroes != 3 {
+			t.Fatal("number of discarded heroes should be public")
+		}
+	}
+	if seen != 3 {
+		t.Fatal("players should see the heroes which they discarded, got ", seen)
+	}
+}
@@ -0,0 +1,177 @@
+package citadels
+
+// destroyCost returns how many coins it takes to destroy the quarter
+func (t *Table) destroyCost(quarter Quarter) int {
+	if quarter.Price < 1 {
+		return 0
+	}
+	return quarter.Price - 1
+}
+
+// destroyQuarter removes completed quarter of target paid by p and puts it to the discard pile
+func (t *Table) destroyQuarter(p *Player, targetID PlayerID, quarterName string) error {
+	d, err := t.destruction(p, targetID, quarterName)
+	if err != nil {
+		return err
+	}
+	target, quarter, cost := d.Target, d.Quarter, d.Cost
+
+	p.AddCoins(-cost)
+	target.Lock()
+	target.CompletedQuarters = removeQuarterByName(target.CompletedQuarters, quarter.Name)
+	target.Unlock()
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeCoinsPaid,
+		Data: EventCoinsPaid{
+			PlayerID: p.ID,
+			Amount:   cost,
+			Sum:      p.Coins,
+		},
+	})
+
+	t.doBroadcastEvent(Event{
+		Type: EventTypeQuarterDestroyed,
+		Data: EventQuarterDestroyed{
+			PlayerID: p.ID,
+			TargetID: target.ID,
+			Quarter:  quarter,
+		},
+	})
+
+	t.salvage(d)
+	return nil
+}
+
+// destruction returns how the quarter of target is destroyed by p or why it can not be destroyed
+func (t *Table) destruction(p *Player, targetID PlayerID, quarterName string) (*Destruction, error) {
+	target, ok := t.playerByID(string(targetID))
+	if !ok {
+		return nil, ErrPlayerNotExists
+	}
+	if target.ID == p.ID {
+		return nil, ErrCannotCastOnMyself
+	}
+
+	if len(target.CompletedQuarters) >= t.rules.CitySize {
+		return nil, ErrCityComplete
+	}
+	if t.protected(target) {
+		return nil, ErrPlayerProtected
+	}
+
+	var quarter Quarter
+	var found bool
+	for _, q := range target.CompletedQuarters {
+		if q.Name == quarterName {
+			quarter = q
+			found = true
+		}
+	}
+	if !found {
+		return nil, ErrQuarterNotExists
+	}
+
+	d := &Destruction{Player: p, Target: target, Quarter: quarter, Cost: t.destroyCost(quarter)}
+	for _, owner := range t.seated() {
+		var err error
+		quarterHooks(owner, func(q Quarter, ability QuarterAbility) {
+			if ability.OnDestroy != nil && err == nil {
+				err = ability.OnDestroy(t, owner, q, d)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if d.Cost > p.Coins {
+		return nil, ErrNotEnoughCoins
+	}
+	return d, nil
+}
+
+// salvage offers destroyed quarter to the player who may pay a coin for it,
+// otherwise the quarter goes to the discard pile
+func (t *Table) salvage(d *Destruction) {
+	if d.SalvagedBy == nil || d.SalvagedBy.Coins < 1 {
+		t.discard = append(t.discard, d.Quarter)
+		return
+	}
+
+	t.salvageOffer = d
+	d.SalvagedBy.Notify(Event{
+		Type: EventTypeChooseSalvage,
+		Data: EventQuarterDestroyed{
+			PlayerID: d.Player.ID,
+			TargetID: d.Target.ID,
+			Quarter:  d.Quarter,
+		},
+	})
+}
+
+// Salvage is answer of the player who is offered destroyed quarter,
+// if take is true he pays a coin and takes the quarter to his hand
+func (t *Table) Salvage(pID string, take bool) error {
+	t.Lock()
+	defer t.Unlock()
+	return t.record(Command{Type: CommandTypeSalvage, PlayerID: PlayerID(pID), Answer: take}, func() error {
+		return t.answerSalvage(PlayerID(pID), take)
+	})
+}
+
+// answerSalvage takes or declines the offered quarter
+func (t *Table) answerSalvage(pID PlayerID, take bool) error {
+	d, err := t.checkSalvage(pID)
+	if err != nil {
+		return err
+	}
+	if !take {
+		t.declineSalvage()
+		return nil
+	}
+	if d.SalvagedBy.Coins < 1 {
+		return ErrNotEnoughCoins
+	}
+
+	t.salvageOffer = nil
+	d.SalvagedBy.AddCoins(-1)
+	d.SalvagedBy.AddQuarter(d.Quarter)
+	t.doBroadcastEvent(Event{
+		Type: EventTypeQuarterSalvaged,
+		Data: EventQuarterDestroyed{
+			PlayerID: d.SalvagedBy.ID,
+			TargetID: d.Target.ID,
+			Quarter:  d.Quarter,
+		},
+	})
+	return nil
+}
+
+// checkSalvage returns destroyed quarter which player with id pID is offered
+func (t *Table) checkSalvage(pID PlayerID) (*Destruction, error) {
+	d := t.salvageOffer
+	if t.currentPhase != ActionPhase || d == nil || d.SalvagedBy.ID != pID {
+		return nil, ErrNoSalvage
+	}
+	return d, nil
+}
+
+// declineSalvage puts offered quarter to the discard pile,
+// the offer is declined when the turn ends without an answer
+func (t *Table) declineSalvage() {
+	d := t.salvageOffer
+	if d == nil {
+		return
+	}
+	t.salvageOffer = nil
+	t.discard = append(t.discard, d.Quarter)
+	t.doBroadcastEvent(Event{
+		Type: EventTypeSalvageDeclined,
+		Data: EventQuarterDestroyed{
+			PlayerID: d.SalvagedBy.ID,
+			TargetID: d.Target.ID,
+			Quarter:  d.Quarter,
+		},
+	})
+}